@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"worker-service/config"
+	"worker-service/infrastructure"
+	"worker-service/internal/dto"
+	"worker-service/internal/repository"
+	"worker-service/internal/repository/unitofwork"
+	"worker-service/internal/services"
+	"worker-service/internal/usecase"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewApiKey() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apikey",
+		Short: "Manage service api keys",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newApiKeyCreate())
+	cmd.AddCommand(newApiKeyList())
+	cmd.AddCommand(newApiKeyRevoke())
+	cmd.AddCommand(newApiKeyRotate())
+
+	return cmd
+}
+
+func initApiKeyUsecase() usecase.ApiKeyUsecase {
+	cfg := config.New()
+	db := infrastructure.InitializeDBConnection(*cfg)
+	signingSecrets, err := services.NewSigningSecrets(*cfg)
+	if err != nil {
+		logrus.Fatal("invalid signing secret key, err: ", err)
+	}
+	return usecase.NewApiKeyUsecase(cfg, repository.NewApiKeyRepository(db), repository.NewApiKeyUsageRepository(db), services.NewApiKeyCache(*cfg), unitofwork.NewUoW(db), signingSecrets)
+}
+
+func newApiKeyCreate() *cobra.Command {
+	var (
+		request   dto.CreateApiKeyRequest
+		expiresAt string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an api key and print its secret once",
+		Run: func(cmd *cobra.Command, args []string) {
+			if expiresAt != "" {
+				t, err := time.Parse(time.RFC3339, expiresAt)
+				if err != nil {
+					logrus.Fatal("invalid --expires-at, expected RFC3339: ", err)
+				}
+				request.ExpiresAt = &t
+			}
+
+			res, err := initApiKeyUsecase().CreateApiKey(context.Background(), request)
+			if err != nil {
+				logrus.Fatal("failed to create api key, err: ", err)
+			}
+			printApiKeySecret(res)
+		},
+	}
+
+	cmd.Flags().StringVar(&request.Name, "name", "", "service name of the key")
+	cmd.Flags().StringVar(&request.TenantID, "tenant", "", "tenant sharing email history, defaults to the key id")
+	cmd.Flags().StringSliceVar(&request.AllowedIPs, "allowed-ip", nil, "allowed ip or cidr, repeatable")
+	cmd.Flags().IntVar(&request.MaxPerMinute, "max-per-minute", 60, "emails per minute")
+	cmd.Flags().IntVar(&request.BurstLimit, "burst", 0, "burst capacity, defaults to max-per-minute")
+	cmd.Flags().IntVar(&request.MaxPerHour, "max-per-hour", 0, "emails per hour, 0 disables it")
+	cmd.Flags().IntVar(&request.MaxPerDay, "max-per-day", 0, "emails per day, 0 disables it")
+	cmd.Flags().StringSliceVar(&request.Scopes, "scope", []string{dto.ScopeEmailsSend, dto.ScopeEmailsRead, dto.ScopeEmailsRetry}, "granted scope, repeatable: "+strings.Join(dto.AvailableScopes, ", "))
+	cmd.Flags().StringVar(&expiresAt, "expires-at", "", "expiry date in RFC3339")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func newApiKeyList() *cobra.Command {
+	var query usecase.ListApiKeyRequestQuery
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List api keys",
+		Run: func(cmd *cobra.Command, args []string) {
+			apiKeyUsecase := initApiKeyUsecase()
+			listApiKey := apiKeyUsecase.ListApiKey
+			if cmd.Flags().Changed("unused-days") {
+				listApiKey = apiKeyUsecase.ListUnusedApiKey
+			}
+
+			res, err := listApiKey(context.Background(), query)
+			if err != nil {
+				logrus.Fatal("failed to list api keys, err: ", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tTENANT\tACTIVE\tSCOPES\tMAX/MIN\tEXPIRES AT\tLAST USED AT\tCREATED AT")
+			for _, apiKey := range res.List {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%d\t%s\t%s\t%s\n", apiKey.ID, apiKey.Name, apiKey.TenantID, apiKey.IsActive, strings.Join(apiKey.Scopes, ","), apiKey.MaxPerMinute, formatOptionalTime(apiKey.ExpiresAt), formatOptionalTime(apiKey.LastUsedAt), apiKey.CreatedAt.Format(time.RFC3339))
+			}
+			w.Flush()
+			fmt.Printf("page %d of %d, %d keys\n", res.Header.CurrentPage, res.Header.TotalPages, res.Header.TotalData)
+		},
+	}
+
+	cmd.Flags().IntVar(&query.Page, "page", 1, "page number")
+	cmd.Flags().IntVar(&query.Limit, "limit", 50, "keys per page")
+	cmd.Flags().BoolVar(&query.IncludeInactive, "all", false, "include revoked keys")
+	cmd.Flags().IntVar(&query.UnusedDays, "unused-days", 0, "only list active keys unused for this many days")
+
+	return cmd
+}
+
+func newApiKeyRevoke() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revoke an api key",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := initApiKeyUsecase().RevokeApiKey(context.Background(), args[0]); err != nil {
+				logrus.Fatal("failed to revoke api key, err: ", err)
+			}
+			fmt.Printf("api key %s revoked\n", args[0])
+		},
+	}
+}
+
+func newApiKeyRotate() *cobra.Command {
+	var request dto.RotateApiKeyRequest
+
+	cmd := &cobra.Command{
+		Use:   "rotate <id>",
+		Short: "Issue a new secret for an api key",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			res, err := initApiKeyUsecase().RotateApiKey(context.Background(), args[0], request)
+			if err != nil {
+				logrus.Fatal("failed to rotate api key, err: ", err)
+			}
+			printApiKeySecret(res)
+		},
+	}
+
+	cmd.Flags().StringVar(&request.Overlap, "overlap", "", "keep the old key valid for this duration, e.g. 24h")
+
+	return cmd
+}
+
+func printApiKeySecret(res dto.CreateApiKeyResponse) {
+	fmt.Printf("id:   %s\n", res.ApiKey.ID)
+	fmt.Printf("name: %s\n", res.ApiKey.Name)
+	fmt.Printf("key:  %s\n", res.Key)
+	if res.SigningSecret != "" {
+		fmt.Printf("signing secret: %s\n", res.SigningSecret)
+	}
+	fmt.Println("store the secrets now, they will not be shown again")
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
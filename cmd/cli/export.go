@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"time"
+	"worker-service/config"
+	"worker-service/infrastructure"
+	"worker-service/internal/repository"
+	"worker-service/internal/usecase"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewExport() *cobra.Command {
+	var (
+		query                                          usecase.ListEmailRequestQuery
+		format, output, month                          string
+		createdFrom, createdTo, updatedFrom, updatedTo string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export email history as CSV or NDJSON",
+		Run: func(cmd *cobra.Command, args []string) {
+			if query.TenantID == "" && !query.AllTenants {
+				logrus.Fatal("either --tenant or --all-tenants is required")
+			}
+
+			query.StartAt = parseExportTime("created-from", createdFrom)
+			query.EndAt = parseExportTime("created-to", createdTo)
+			query.UpdatedFrom = parseExportTime("updated-from", updatedFrom)
+			query.UpdatedTo = parseExportTime("updated-to", updatedTo)
+			if month != "" {
+				start, err := time.Parse("2006-01", month)
+				if err != nil {
+					logrus.Fatal("invalid --month, expected YYYY-MM: ", err)
+				}
+				query.StartAt = start
+				query.EndAt = start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+			}
+
+			out := os.Stdout
+			if output != "-" {
+				file, err := os.Create(output)
+				if err != nil {
+					logrus.Fatal("failed to create output file, err: ", err)
+				}
+				defer file.Close()
+				out = file
+			}
+
+			cfg := config.New()
+			db := infrastructure.InitializeDBConnection(*cfg)
+			exportUsecase := usecase.NewEmailExportUsecase(repository.NewEmailHistoryRepository(db))
+			if err := exportUsecase.ExportEmail(context.Background(), query, format, out); err != nil {
+				logrus.Fatal("failed to export emails, err: ", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", usecase.ExportFormatCSV, "csv or ndjson")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file, - writes to stdout")
+	cmd.Flags().StringVar(&query.TenantID, "tenant", "", "tenant to export")
+	cmd.Flags().BoolVar(&query.AllTenants, "all-tenants", false, "export every tenant")
+	cmd.Flags().StringVar(&month, "month", "", "export emails created in this month (YYYY-MM, UTC)")
+	cmd.Flags().StringVar(&createdFrom, "created-from", "", "created at or after, RFC3339")
+	cmd.Flags().StringVar(&createdTo, "created-to", "", "created at or before, RFC3339")
+	cmd.Flags().StringVar(&updatedFrom, "updated-from", "", "updated at or after, RFC3339")
+	cmd.Flags().StringVar(&updatedTo, "updated-to", "", "updated at or before, RFC3339")
+	cmd.Flags().StringSliceVar(&query.Status, "status", nil, "PENDING, SUCCESS or FAILED, repeatable")
+	cmd.Flags().StringVar(&query.To, "to", "", "recipient contains")
+	cmd.Flags().StringVar(&query.From, "from", "", "sender contains")
+	cmd.Flags().StringVar(&query.Subject, "subject", "", "subject filter")
+	cmd.Flags().StringVar(&query.SubjectMatch, "subject-match", "contains", "contains or prefix")
+	cmd.Flags().StringVar(&query.Search, "q", "", "full text search over subject and body")
+	cmd.Flags().StringVar(&query.Sort, "sort", "", "created_at, updated_at or rank")
+	cmd.Flags().BoolVar(&query.IsAscending, "ascending", false, "oldest first")
+	cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+func parseExportTime(flag string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logrus.Fatalf("invalid --%s, expected RFC3339: %v", flag, err)
+	}
+	return t
+}
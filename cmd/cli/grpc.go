@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"worker-service/config"
+	"worker-service/infrastructure"
+	"worker-service/internal/delivery/grpc"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewGrpc() *cobra.Command {
+	return &cobra.Command{
+		Use:     "run-grpc",
+		Aliases: []string{"grpc"},
+		Short:   "Run gRPC server",
+		Run: func(cmd *cobra.Command, args []string) {
+			logrus.Info("Running gRPC server...")
+
+			// Init
+			appConfig := config.New()
+			db := infrastructure.InitializeDBConnection(*appConfig)
+			server := grpc.InitServer(db)
+
+			listener, err := net.Listen("tcp", appConfig.Server.GrpcAddress)
+			if err != nil {
+				logrus.Fatal("failed to listen, err: ", err)
+			}
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+			go func() {
+				<-sigChan
+				logrus.Info("Received signal, stopping gRPC server")
+				server.GracefulStop()
+			}()
+
+			if err := server.Serve(listener); err != nil {
+				logrus.Error(err)
+			}
+		},
+	}
+}
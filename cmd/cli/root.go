@@ -19,7 +19,10 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(NewWorker())
 	rootCmd.AddCommand(NewApp())
+	rootCmd.AddCommand(NewGrpc())
 	rootCmd.AddCommand(NewMigrate())
+	rootCmd.AddCommand(NewApiKey())
+	rootCmd.AddCommand(NewExport())
 }
 
 func Execute() {
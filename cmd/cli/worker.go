@@ -6,10 +6,13 @@ import (
 	"os/signal"
 	"syscall"
 	"worker-service/config"
+	"worker-service/infrastructure"
 	"worker-service/internal/delivery/workers"
 	tasks "worker-service/internal/dto"
 	"worker-service/internal/pkg/redis"
+	"worker-service/internal/repository"
 	"worker-service/internal/services"
+	"worker-service/internal/usecase"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -27,23 +30,39 @@ func NewWorker() *cobra.Command {
 
 			// Init
 			appConfig := config.New()
-			redisClient := redis.NewRedisClient[tasks.EmailTask](*appConfig, "email_queue", 0)
+			db := infrastructure.InitializeDBConnection(*appConfig)
+			redisClient := redis.NewRedisClient[tasks.QueuedEmail](*appConfig, "email_queue", 0)
 			emailService := services.NewEmailService(*appConfig)
-			w := workers.NewEmailWorker(redisClient, emailService)
+			messageStore, err := repository.NewEmailMessageStore(appConfig.Messages.Driver, appConfig.Messages.Path, db)
+			if err != nil {
+				logrus.Fatal("failed to open message store, err: ", err)
+			}
+			usageTracker := services.NewUsageTracker(repository.NewApiKeyRepository(db), repository.NewApiKeyUsageRepository(db), appConfig.Usage.FlushInterval)
+			emailClaims := redis.NewRedisClient[bool](*appConfig, "email_queue:claim", usecase.EmailClaimTTL)
+			deliveryUsecase := usecase.NewEmailDeliveryUsecase(repository.NewEmailHistoryRepository(db), emailService, messageStore, usageTracker, services.NewEmailEvents(*appConfig), repository.NewBatchRepository(db), emailClaims)
+			w := workers.NewEmailWorker(redisClient, deliveryUsecase)
 
 			sigChan := make(chan os.Signal, 1)
 			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 			ctx, cancel := context.WithCancel(context.Background())
+			trackerDone := make(chan struct{})
+			go func() {
+				defer close(trackerDone)
+				usageTracker.Run(ctx)
+			}()
 			go func() {
 				<-sigChan
 				logrus.Info("Received signal, canceling root context")
 				cancel()
-				os.Exit(1)
 			}()
 
 			if err := w.Run(ctx); err != nil {
 				logrus.Error(err)
 			}
+
+			// Exit only once the usage tracked so far is flushed
+			cancel()
+			<-trackerDone
 		},
 	}
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -27,16 +29,107 @@ type DBConfig struct {
 	MaxOpenConnection int    `mapstructure:"max_open_connection"`
 }
 
+type ServerConfig struct {
+	// TrustedProxies lists the proxy IPs or CIDRs allowed to set X-Forwarded-For.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// GrpcAddress is where run-grpc listens.
+	GrpcAddress string `mapstructure:"grpc_address"`
+}
+
+type AuthConfig struct {
+	CacheTTL       time.Duration `mapstructure:"cache_ttl"`
+	LocalCacheTTL  time.Duration `mapstructure:"local_cache_ttl"`
+	LocalCacheSize int           `mapstructure:"local_cache_size"`
+	// SignatureMaxSkew bounds the clock difference accepted on signed requests.
+	SignatureMaxSkew time.Duration `mapstructure:"signature_max_skew"`
+	// SigningSecretKey is the base64 32 byte key the request signing secrets
+	// of api keys are encrypted with. Request signing is off without it.
+	SigningSecretKey string `mapstructure:"signing_secret_key"`
+	// An ip is locked out after LockoutThreshold failed authentications within
+	// LockoutWindow. Every further lockout before LockoutDecay has passed doubles
+	// LockoutDuration, up to MaxLockoutDuration.
+	LockoutThreshold   int           `mapstructure:"lockout_threshold"`
+	LockoutWindow      time.Duration `mapstructure:"lockout_window"`
+	LockoutDuration    time.Duration `mapstructure:"lockout_duration"`
+	MaxLockoutDuration time.Duration `mapstructure:"max_lockout_duration"`
+	LockoutDecay       time.Duration `mapstructure:"lockout_decay"`
+}
+
+// AdminConfig configures the JWTs operators use on admin endpoints. HS256
+// tokens are verified with JWTSecret, RS256 tokens with the keys of JWKSFile
+// or JWKSURL.
+type AdminConfig struct {
+	JWTSecret     string        `mapstructure:"jwt_secret"`
+	JWKSFile      string        `mapstructure:"jwks_file"`
+	JWKSURL       string        `mapstructure:"jwks_url"`
+	JWKSRefresh   time.Duration `mapstructure:"jwks_refresh"`
+	Issuer        string        `mapstructure:"issuer"`
+	Audience      string        `mapstructure:"audience"`
+	RoleClaim     string        `mapstructure:"role_claim"`
+	AllowedLeeway time.Duration `mapstructure:"allowed_leeway"`
+}
+
+type UsageConfig struct {
+	FlushInterval   time.Duration `mapstructure:"flush_interval"`
+	UnusedAfterDays int           `mapstructure:"unused_after_days"`
+}
+
+// MessageStoreConfig selects where rendered messages are kept, "postgres" or
+// "filesystem" under Path.
+type MessageStoreConfig struct {
+	Driver string `mapstructure:"driver"`
+	Path   string `mapstructure:"path"`
+}
+
+// EventsConfig bounds the email status events kept for subscribers resuming
+// with Last-Event-ID.
+type EventsConfig struct {
+	StreamLength int64 `mapstructure:"stream_length"`
+}
+
+// BulkConfig applies backpressure to streamed bulk uploads, they wait while
+// the email queue holds MaxQueueLength emails or more.
+type BulkConfig struct {
+	MaxQueueLength int64 `mapstructure:"max_queue_length"`
+}
+
 type AppConfig struct {
-	Redis    RedisConfig `mapstructure:"redis"`
-	Smtp     SmtpConfig  `mapstructure:"smtp"`
-	DBConfig DBConfig    `mapstructure:"database"`
+	Redis    RedisConfig        `mapstructure:"redis"`
+	Smtp     SmtpConfig         `mapstructure:"smtp"`
+	DBConfig DBConfig           `mapstructure:"database"`
+	Server   ServerConfig       `mapstructure:"server"`
+	Auth     AuthConfig         `mapstructure:"auth"`
+	Admin    AdminConfig        `mapstructure:"admin"`
+	Usage    UsageConfig        `mapstructure:"usage"`
+	Messages MessageStoreConfig `mapstructure:"message_store"`
+	Events   EventsConfig       `mapstructure:"events"`
+	Bulk     BulkConfig         `mapstructure:"bulk"`
 }
 
 func init() {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
+
+	viper.SetDefault("auth.cache_ttl", 30*time.Second)
+	viper.SetDefault("auth.local_cache_ttl", 5*time.Second)
+	viper.SetDefault("auth.local_cache_size", 1024)
+	viper.SetDefault("auth.signature_max_skew", 5*time.Minute)
+	viper.SetDefault("auth.lockout_threshold", 10)
+	viper.SetDefault("auth.lockout_window", 10*time.Minute)
+	viper.SetDefault("auth.lockout_duration", time.Minute)
+	viper.SetDefault("auth.max_lockout_duration", 24*time.Hour)
+	viper.SetDefault("auth.lockout_decay", 24*time.Hour)
+	viper.SetDefault("admin.jwks_refresh", 10*time.Minute)
+	viper.SetDefault("admin.role_claim", "roles")
+	viper.SetDefault("admin.allowed_leeway", 30*time.Second)
+	viper.SetDefault("usage.flush_interval", 10*time.Second)
+	viper.SetDefault("usage.unused_after_days", 30)
+	viper.SetDefault("server.grpc_address", ":9090")
+	viper.SetDefault("message_store.driver", "postgres")
+	viper.SetDefault("message_store.path", "data/messages")
+	viper.SetDefault("events.stream_length", 10000)
+	viper.SetDefault("bulk.max_queue_length", 50000)
 }
 
 func New() *AppConfig {
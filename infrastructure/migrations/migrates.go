@@ -3,7 +3,9 @@ package migrations
 import (
 	"fmt"
 	"worker-service/internal/dto"
+	"worker-service/internal/repository"
 
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -13,9 +15,53 @@ func MigrateAll(db *gorm.DB) {
 	err := db.AutoMigrate(
 		&dto.EmailHistory{},
 		&dto.ApiKey{},
+		&dto.ApiKeyUsage{},
+		&dto.EmailJob{},
+		&dto.EmailMessage{},
+		&dto.Batch{},
 	)
 	if err != nil {
 		logrus.Panic(fmt.Sprintf("failed to migrate all table, err: %v", err))
 	}
+
+	// Keys created before scopes existed keep the access they always had
+	err = db.Model(&dto.ApiKey{}).Where("scopes IS NULL").
+		Update("scopes", pq.StringArray{dto.ScopeEmailsSend, dto.ScopeEmailsRead, dto.ScopeEmailsRetry}).Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to backfill api key scopes, err: %v", err))
+	}
+	// Keys created before tenants existed own their history alone
+	err = db.Model(&dto.ApiKey{}).Where("tenant_id IS NULL OR tenant_id = ''").
+		Update("tenant_id", gorm.Expr("id")).Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to backfill api key tenants, err: %v", err))
+	}
+	// Histories are paginated by (updated_at, id), which needs updated_at set
+	err = db.Model(&dto.EmailHistory{}).Where("updated_at IS NULL").
+		UpdateColumn("updated_at", gorm.Expr("created_at")).Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to backfill email history updated_at, err: %v", err))
+	}
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_email_histories_tenant_updated_at_id ON email_histories (tenant_id, updated_at, id)").Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to create email history cursor index, err: %v", err))
+	}
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_email_histories_updated_at_id ON email_histories (updated_at, id)").Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to create email history cursor index, err: %v", err))
+	}
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_email_histories_tenant_created_at_id ON email_histories (tenant_id, created_at, id)").Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to create email history cursor index, err: %v", err))
+	}
+	// Index histories recorded before full text search existed
+	err = db.Model(&dto.EmailHistory{}).Where("search_vector IS NULL").
+		UpdateColumn("search_vector", gorm.Expr(
+			"setweight(to_tsvector(?::regconfig, coalesce(subject, '')), 'A') || setweight(to_tsvector(?::regconfig, regexp_replace(coalesce(body, ''), '<[^>]*>', ' ', 'g')), 'B')",
+			repository.SearchLanguage, repository.SearchLanguage,
+		)).Error
+	if err != nil {
+		logrus.Panic(fmt.Sprintf("failed to backfill email history search vector, err: %v", err))
+	}
 	logrus.Info("Migration finished!")
 }
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"strconv"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AdminApiKeyPath       = "/admin/api-keys"
+	AdminApiKeyRevokePath = "/admin/api-keys/:id/revoke"
+	AdminApiKeyRotatePath = "/admin/api-keys/:id/rotate"
+	AdminApiKeyUsagePath  = "/admin/api-keys/:id/usage"
+	AdminApiKeyUnusedPath = "/admin/api-keys/unused"
+
+	defaultUsageDays = 30
+)
+
+type apiKeyController struct {
+	apiKeyUsecase usecase.ApiKeyUsecase
+}
+
+type ApiKeyController interface {
+	CreateApiKey(ctx *gin.Context)
+	ListApiKey(ctx *gin.Context)
+	RevokeApiKey(ctx *gin.Context)
+	RotateApiKey(ctx *gin.Context)
+	GetApiKeyUsage(ctx *gin.Context)
+	ListUnusedApiKey(ctx *gin.Context)
+}
+
+func NewApiKeyController(apiKeyUsecase usecase.ApiKeyUsecase) ApiKeyController {
+	return &apiKeyController{
+		apiKeyUsecase: apiKeyUsecase,
+	}
+}
+
+func (c *apiKeyController) CreateApiKey(ctx *gin.Context) {
+	var request dto.CreateApiKeyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+		return
+	}
+
+	data, err := c.apiKeyUsecase.CreateApiKey(ctx, request)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *apiKeyController) ListApiKey(ctx *gin.Context) {
+	pagination := ParsePagination(ctx)
+
+	includeInactive, err := strconv.ParseBool(ctx.Query("include_inactive"))
+	if err != nil {
+		includeInactive = false
+	}
+
+	data, err := c.apiKeyUsecase.ListApiKey(ctx, usecase.ListApiKeyRequestQuery{
+		Page:            pagination.Page,
+		Limit:           pagination.Limit,
+		IncludeInactive: includeInactive,
+	})
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *apiKeyController) RevokeApiKey(ctx *gin.Context) {
+	if err := c.apiKeyUsecase.RevokeApiKey(ctx, ctx.Param("id")); err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, nil)
+}
+
+func (c *apiKeyController) RotateApiKey(ctx *gin.Context) {
+	var request dto.RotateApiKeyRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+			return
+		}
+	}
+
+	data, err := c.apiKeyUsecase.RotateApiKey(ctx, ctx.Param("id"), request)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *apiKeyController) GetApiKeyUsage(ctx *gin.Context) {
+	days, err := ParseQueryToInt(ctx, "days")
+	if err != nil || days < 0 {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+		return
+	}
+	if days == 0 {
+		days = defaultUsageDays
+	}
+
+	data, err := c.apiKeyUsecase.GetApiKeyUsage(ctx, ctx.Param("id"), days)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *apiKeyController) ListUnusedApiKey(ctx *gin.Context) {
+	pagination := ParsePagination(ctx)
+
+	days, err := ParseQueryToInt(ctx, "days")
+	if err != nil || days < 0 {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+		return
+	}
+
+	data, err := c.apiKeyUsecase.ListUnusedApiKey(ctx, usecase.ListApiKeyRequestQuery{
+		Page:       pagination.Page,
+		Limit:      pagination.Limit,
+		UnusedDays: days,
+	})
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"worker-service/internal/dto"
+	"worker-service/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	BatchByIdPath   = "/batches/:id"
+	BatchEmailsPath = "/batches/:id/emails"
+	BatchPausePath  = "/batches/:id/pause"
+	BatchResumePath = "/batches/:id/resume"
+	BatchCancelPath = "/batches/:id/cancel"
+)
+
+type batchController struct {
+	batchUsecase usecase.BatchUsecase
+}
+
+type BatchController interface {
+	GetBatch(ctx *gin.Context)
+	ListBatchEmails(ctx *gin.Context)
+	PauseBatch(ctx *gin.Context)
+	ResumeBatch(ctx *gin.Context)
+	CancelBatch(ctx *gin.Context)
+}
+
+func NewBatchController(batchUsecase usecase.BatchUsecase) BatchController {
+	return &batchController{
+		batchUsecase: batchUsecase,
+	}
+}
+
+func (c *batchController) GetBatch(ctx *gin.Context) {
+	c.handleBatch(ctx, c.batchUsecase.GetBatch)
+}
+
+// ListBatchEmails lists the emails of the batch with the filters of ListEmail.
+func (c *batchController) ListBatchEmails(ctx *gin.Context) {
+	query, err := parseListEmailQuery(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+	query.BatchID = ctx.Param("id")
+
+	data, err := c.batchUsecase.ListBatchEmails(ctx, query)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *batchController) PauseBatch(ctx *gin.Context) {
+	c.handleBatch(ctx, c.batchUsecase.PauseBatch)
+}
+
+func (c *batchController) ResumeBatch(ctx *gin.Context) {
+	c.handleBatch(ctx, c.batchUsecase.ResumeBatch)
+}
+
+func (c *batchController) CancelBatch(ctx *gin.Context) {
+	c.handleBatch(ctx, c.batchUsecase.CancelBatch)
+}
+
+// handleBatch runs action on the batch of the path and writes the batch it returns.
+func (c *batchController) handleBatch(ctx *gin.Context, action func(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error)) {
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	data, err := action(ctx, scope, ctx.Param("id"))
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
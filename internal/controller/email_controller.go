@@ -1,12 +1,19 @@
 package controller
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 	"worker-service/internal/dto"
 	"worker-service/internal/pkg/error_wrap"
 	"worker-service/internal/usecase"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -14,26 +21,107 @@ const (
 	EmailByIdPath     = "/emails/:id"
 	EmailSendBulkPath = "/emails/bulk"
 	EmailRetryPath    = "/emails/retry"
+	EmailExportPath   = "/emails/export"
+	EmailRawPath      = "/emails/:id/raw"
+	EmailIngestPath   = "/emails/bulk/stream"
 )
 
+// maxSendWait caps how long a single send may hold the request open.
+const maxSendWait = 30 * time.Second
+
+const ndjsonContentType = "application/x-ndjson"
+
+// maxIngestLineSize fits the largest email that passes validation.
+const maxIngestLineSize = 2 * dto.MaxEmailBodySize
+
 type emailController struct {
-	emailUsecase usecase.EmailUsecase
+	emailUsecase       usecase.EmailUsecase
+	emailExportUsecase usecase.EmailExportUsecase
 }
 
 type EmailController interface {
 	ListEmail(ctx *gin.Context)
 	ListEmailByID(ctx *gin.Context)
+	ExportEmail(ctx *gin.Context)
+	GetRawEmail(ctx *gin.Context)
 	RetryEmail(ctx *gin.Context)
 	SendEmail(ctx *gin.Context)
+	SendSingleEmail(ctx *gin.Context)
+	IngestEmails(ctx *gin.Context)
 }
 
-func NewEmailController(emailUsecase usecase.EmailUsecase) EmailController {
+func NewEmailController(emailUsecase usecase.EmailUsecase, emailExportUsecase usecase.EmailExportUsecase) EmailController {
 	return &emailController{
-		emailUsecase: emailUsecase,
+		emailUsecase:       emailUsecase,
+		emailExportUsecase: emailExportUsecase,
 	}
 }
 
 func (c *emailController) ListEmail(ctx *gin.Context) {
+	query, err := parseListEmailQuery(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	data, err := c.emailUsecase.ListEmail(ctx, query)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+// ExportEmail streams every email matching the ListEmail filters as a download.
+func (c *emailController) ExportEmail(ctx *gin.Context) {
+	query, err := parseListEmailQuery(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	format := ctx.DefaultQuery("format", usecase.ExportFormatCSV)
+	contentType, isExist := usecase.ExportContentTypes[format]
+	if !isExist {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+		return
+	}
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="emails-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), format))
+	if err := c.emailExportUsecase.ExportEmail(ctx, query, format, ctx.Writer); err != nil {
+		// Once rows were streamed the status is sent, the connection is dropped
+		// so the download fails instead of ending in a truncated file
+		if ctx.Writer.Written() {
+			panic(http.ErrAbortHandler)
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		dto.WriteErrorResponseJSON(ctx, err)
+	}
+}
+
+// GetRawEmail downloads the sent message of an email as an .eml file.
+func (c *emailController) GetRawEmail(ctx *gin.Context) {
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	message, err := c.emailUsecase.GetRawEmail(ctx, scope, ctx.Param("id"))
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.eml"`, message.EmailID))
+	ctx.Data(http.StatusOK, "message/rfc822", message.Raw)
+}
+
+// parseListEmailQuery reads the filters shared by listing and exporting emails.
+func parseListEmailQuery(ctx *gin.Context) (usecase.ListEmailRequestQuery, error) {
 	pagination := ParsePagination(ctx)
 
 	isAscendingStr := ctx.Query("is_ascending")
@@ -42,70 +130,284 @@ func (c *emailController) ListEmail(ctx *gin.Context) {
 		isAscending = false
 	}
 
+	includeTotal, err := strconv.ParseBool(ctx.Query("include_total"))
+	if err != nil {
+		includeTotal = false
+	}
+
 	status := ctx.QueryArray("status")
 
-	data, err := c.emailUsecase.ListEmail(ctx, usecase.ListEmailRequestQuery{
-		Page:        pagination.Page,
-		Limit:       pagination.Limit,
-		IsAscending: isAscending,
-		Status:      status,
-	})
+	var dateRange [4]time.Time
+	for i, name := range []string{"created_from", "created_to", "updated_from", "updated_to"} {
+		if dateRange[i], err = ParseQueryToTime(ctx, name); err != nil {
+			return usecase.ListEmailRequestQuery{}, err
+		}
+	}
+
+	scope, err := GetTenantScope(ctx)
 	if err != nil {
-		dto.WriteErrorResponseJSON(ctx, err)
-		return
+		return usecase.ListEmailRequestQuery{}, err
 	}
 
-	dto.SuccessResponse.Data = data
-	dto.WriteResponseJSON(ctx, dto.SuccessResponse)
+	return usecase.ListEmailRequestQuery{
+		TenantScope:  scope,
+		StartAt:      dateRange[0],
+		EndAt:        dateRange[1],
+		UpdatedFrom:  dateRange[2],
+		UpdatedTo:    dateRange[3],
+		Cursor:       ctx.Query("cursor"),
+		Limit:        pagination.Limit,
+		IncludeTotal: includeTotal,
+		Sort:         ctx.Query("sort"),
+		IsAscending:  isAscending,
+		Status:       status,
+		To:           ctx.Query("to"),
+		From:         ctx.Query("from"),
+		Subject:      ctx.Query("subject"),
+		SubjectMatch: ctx.Query("subject_match"),
+		Search:       ctx.Query("q"),
+	}, nil
 }
 
 func (c *emailController) ListEmailByID(ctx *gin.Context) {
 	pagination := ParsePagination(ctx)
 	emailID := ctx.Param("id")
 
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
 	data, err := c.emailUsecase.ListEmail(ctx, usecase.ListEmailRequestQuery{
-		Page:  pagination.Page,
-		Limit: pagination.Limit,
-		ID:    emailID,
+		TenantScope: scope,
+		Cursor:      ctx.Query("cursor"),
+		Limit:       pagination.Limit,
+		ID:          emailID,
 	})
 	if err != nil {
 		dto.WriteErrorResponseJSON(ctx, err)
 		return
 	}
 
-	dto.SuccessResponse.Data = data
-	dto.WriteResponseJSON(ctx, dto.SuccessResponse)
+	dto.WriteResponseJSON(ctx, data)
 }
 
 func (c *emailController) RetryEmail(ctx *gin.Context) {
 	var request dto.RetryEmailRequest
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+	if err := BindJSON(ctx, &request); err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+	if errs := request.Validate(); len(errs) > 0 {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ValidationErrors(errs))
 		return
 	}
 
-	if err := c.emailUsecase.RetryEmail(ctx, request.ID); err != nil {
-		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrInternalServerError)
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
 		return
 	}
 
-	dto.SuccessResponse.Data = nil
-	dto.WriteResponseJSON(ctx, dto.SuccessResponse)
+	apiKey, quota, err := GetAPIKeyQuota(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	usage, err := c.emailUsecase.RetryEmail(ctx, usecase.RetryEmailRequest{
+		TenantScope: scope,
+		APIKey:      apiKey,
+		Quota:       quota,
+		ID:          request.ID,
+	})
+	dto.WriteRateLimitHeaders(ctx, usage)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, nil)
 }
 
+// SendEmail queues the emails as one batch, the workers send them.
 func (c *emailController) SendEmail(ctx *gin.Context) {
 	var request []dto.EmailTask
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+	if err := BindJSON(ctx, &request); err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+	if err := dto.ValidateEmailTasks(request); err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	apiKey, quota, err := GetAPIKeyQuota(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
 		return
 	}
 
-	resp, err := c.emailUsecase.SendEmail(ctx, request)
+	allowPartial, err := strconv.ParseBool(ctx.Query("partial"))
 	if err != nil {
-		dto.WriteErrorResponseJSON(ctx, error_wrap.ErrInternalServerError)
+		allowPartial = false
+	}
+
+	resp, err := c.emailUsecase.SendEmail(ctx, usecase.SendEmailRequest{
+		APIKey:       apiKey,
+		ApiKeyID:     ctx.GetString("api_key_id"),
+		TenantID:     ctx.GetString("tenant_id"),
+		Quota:        quota,
+		AllowPartial: allowPartial,
+		Emails:       request,
+	})
+	dto.WriteRateLimitHeaders(ctx, resp.Quota)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
 		return
 	}
 
-	dto.SuccessResponse.Data = resp
-	dto.WriteResponseJSON(ctx, dto.SuccessResponse)
+	dto.WriteAcceptedResponseJSON(ctx, resp)
+}
+
+// SendSingleEmail queues one email. With ?wait the SMTP result is awaited up
+// to that long, otherwise or when it takes longer 202 is returned and the
+// email is reported as queued.
+func (c *emailController) SendSingleEmail(ctx *gin.Context) {
+	var request dto.EmailTask
+	if err := BindJSON(ctx, &request); err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+	if errs := request.Validate(); len(errs) > 0 {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ValidationErrors(errs))
+		return
+	}
+
+	var wait time.Duration
+	if waitStr := ctx.Query("wait"); waitStr != "" {
+		var err error
+		wait, err = time.ParseDuration(waitStr)
+		if err != nil || wait < 0 || wait > maxSendWait {
+			dto.WriteErrorResponseJSON(ctx, error_wrap.ErrBadRequest)
+			return
+		}
+	}
+
+	apiKey, quota, err := GetAPIKeyQuota(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	resp, err := c.emailUsecase.SendSingleEmail(ctx, usecase.SendSingleEmailRequest{
+		APIKey:   apiKey,
+		ApiKeyID: ctx.GetString("api_key_id"),
+		TenantID: ctx.GetString("tenant_id"),
+		Quota:    quota,
+		Email:    request,
+		Wait:     wait,
+	})
+	dto.WriteRateLimitHeaders(ctx, resp.Quota)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	if resp.IsQueued() {
+		dto.WriteAcceptedResponseJSON(ctx, resp)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, resp)
+}
+
+// ingestSummaryLine ends the result stream of an upload.
+type ingestSummaryLine struct {
+	Summary usecase.IngestEmailsSummary `json:"summary"`
+}
+
+// IngestEmails queues an application/x-ndjson upload of one email per line
+// into one batch while it streams in, and streams back a result per line and
+// a summary last. Lines are read only as fast as they are queued, so a full
+// email queue slows the upload down rather than filling memory.
+func (c *emailController) IngestEmails(ctx *gin.Context) {
+	if ctx.ContentType() != ndjsonContentType {
+		dto.WriteErrorResponseJSON(ctx, error_wrap.ValidationErrors{{
+			Field:   "Content-Type",
+			Code:    error_wrap.CodeInvalidType,
+			Message: "must be " + ndjsonContentType,
+		}})
+		return
+	}
+
+	apiKey, quota, err := GetAPIKeyQuota(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	// Only the request context is cancelled when the client goes away
+	requestCtx := ctx.Request.Context()
+	ingestion, err := c.emailUsecase.StartEmailIngestion(requestCtx, usecase.IngestEmailsRequest{
+		APIKey:   apiKey,
+		ApiKeyID: ctx.GetString("api_key_id"),
+		TenantID: ctx.GetString("tenant_id"),
+		Quota:    quota,
+	})
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	// Results are written while the upload is still being read
+	if err := http.NewResponseController(ctx.Writer).EnableFullDuplex(); err != nil {
+		logrus.Warn("error enabling full duplex: ", err)
+	}
+	ctx.Header("Content-Type", ndjsonContentType)
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusAccepted)
+	ctx.Writer.Flush()
+
+	var (
+		encoder = json.NewEncoder(ctx.Writer)
+		scanner = bufio.NewScanner(ctx.Request.Body)
+		line    int
+		stopErr error
+	)
+	scanner.Buffer(nil, maxIngestLineSize)
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var (
+			email  dto.EmailTask
+			result usecase.IngestEmailResult
+		)
+		if err := json.Unmarshal(scanner.Bytes(), &email); err != nil {
+			result = ingestion.Invalid(line, decodeValidationErrors(err))
+		} else if errs := email.Validate(); len(errs) > 0 {
+			result = ingestion.Invalid(line, error_wrap.ValidationErrors(errs))
+		} else if result, stopErr = ingestion.Queue(requestCtx, line, email); stopErr != nil {
+			stopErr = fmt.Errorf("line %d: %w", line, stopErr)
+			break
+		}
+
+		if err := encoder.Encode(result); err != nil {
+			stopErr = err
+			break
+		}
+		ctx.Writer.Flush()
+	}
+	if err := scanner.Err(); err != nil && stopErr == nil {
+		stopErr = fmt.Errorf("line %d: %w", line+1, err)
+	}
+
+	if err := encoder.Encode(ingestSummaryLine{Summary: ingestion.Finish(requestCtx, stopErr)}); err != nil {
+		return
+	}
+	ctx.Writer.Flush()
 }
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"worker-service/internal/dto"
+	"worker-service/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const EmailEventsPath = "/emails/events"
+
+// eventHeartbeat keeps idle event streams from being closed by proxies.
+const eventHeartbeat = 15 * time.Second
+
+type emailEventController struct {
+	emailEventUsecase usecase.EmailEventUsecase
+}
+
+type EmailEventController interface {
+	StreamEvents(ctx *gin.Context)
+}
+
+func NewEmailEventController(emailEventUsecase usecase.EmailEventUsecase) EmailEventController {
+	return &emailEventController{
+		emailEventUsecase: emailEventUsecase,
+	}
+}
+
+// StreamEvents sends the status changes of the emails of the caller as
+// Server-Sent Events. Reconnecting clients send the ID of the last event they
+// got as Last-Event-ID, or last_event_id, and receive what they missed first.
+func (c *emailEventController) StreamEvents(ctx *gin.Context) {
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+
+	// Only the request context is cancelled when the client goes away
+	requestCtx := ctx.Request.Context()
+	events, err := c.emailEventUsecase.SubscribeEvents(requestCtx, usecase.SubscribeEmailEventsRequest{
+		TenantScope: scope,
+		LastEventID: lastEventID,
+		EmailID:     ctx.Query("email_id"),
+		MessageID:   ctx.Query("message_id"),
+		BatchID:     ctx.Query("batch_id"),
+	})
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-requestCtx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logrus.Error("error encoding email event: ", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(ctx.Writer, "id: %s\nevent: status\ndata: %s\n\n", event.ID, data); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
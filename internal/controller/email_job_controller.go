@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EmailRetryBulkPath  = "/emails/retry/bulk"
+	EmailCancelBulkPath = "/emails/cancel/bulk"
+	EmailJobByIdPath    = "/emails/jobs/:id"
+)
+
+type emailJobController struct {
+	emailJobUsecase usecase.EmailJobUsecase
+}
+
+type EmailJobController interface {
+	RetryEmails(ctx *gin.Context)
+	CancelEmails(ctx *gin.Context)
+	GetEmailJob(ctx *gin.Context)
+}
+
+func NewEmailJobController(emailJobUsecase usecase.EmailJobUsecase) EmailJobController {
+	return &emailJobController{
+		emailJobUsecase: emailJobUsecase,
+	}
+}
+
+func (c *emailJobController) RetryEmails(ctx *gin.Context) {
+	request, err := parseBulkEmailRequest(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	data, err := c.emailJobUsecase.RetryEmails(ctx, request)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *emailJobController) CancelEmails(ctx *gin.Context) {
+	request, err := parseBulkEmailRequest(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	data, err := c.emailJobUsecase.CancelEmails(ctx, request)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func (c *emailJobController) GetEmailJob(ctx *gin.Context) {
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	data, err := c.emailJobUsecase.GetEmailJob(ctx, scope, ctx.Param("id"))
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
+
+func parseBulkEmailRequest(ctx *gin.Context) (usecase.BulkEmailJobRequest, error) {
+	var request dto.BulkEmailRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return usecase.BulkEmailJobRequest{}, error_wrap.ErrBadRequest
+	}
+
+	scope, err := GetTenantScope(ctx)
+	if err != nil {
+		return usecase.BulkEmailJobRequest{}, err
+	}
+
+	apiKey, quota, err := GetAPIKeyQuota(ctx)
+	if err != nil {
+		return usecase.BulkEmailJobRequest{}, err
+	}
+
+	return usecase.BulkEmailJobRequest{
+		TenantScope:      scope,
+		BulkEmailRequest: request,
+		APIKey:           apiKey,
+		ApiKeyID:         ctx.GetString("api_key_id"),
+		Quota:            quota,
+	}, nil
+}
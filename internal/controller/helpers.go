@@ -1,8 +1,14 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
+	"worker-service/internal/dto"
 	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/usecase"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,13 +23,14 @@ func ParsePagination(ctx *gin.Context) PaginationReq {
 	var result PaginationReq
 
 	result.Page, err = ParseQueryToInt(ctx, "page")
-	if err != nil || result.Page == 0 {
+	if err != nil || result.Page < 1 {
 		result.Page = 1
 	}
 	result.Limit, err = ParseQueryToInt(ctx, "limit")
-	if err != nil || result.Limit == 0 {
-		result.Limit = 10
+	if err != nil {
+		result.Limit = 0
 	}
+	result.Limit = usecase.PageLimit(result.Limit)
 
 	return result
 }
@@ -40,3 +47,64 @@ func ParseQueryToInt(ctx *gin.Context, query string) (int, error) {
 
 	return int(queryInt), nil
 }
+
+// ParseQueryToTime parses an RFC3339 query parameter, a missing one is the zero time.
+func ParseQueryToTime(ctx *gin.Context, query string) (time.Time, error) {
+	queryStr := ctx.Query(query)
+	if queryStr == "" {
+		return time.Time{}, nil
+	}
+	queryTime, err := time.Parse(time.RFC3339, queryStr)
+	if err != nil {
+		return time.Time{}, error_wrap.ErrBadRequest
+	}
+
+	return queryTime, nil
+}
+
+// BindJSON decodes the request body into request, a body that can not be
+// decoded is reported as a validation error.
+func BindJSON(ctx *gin.Context, request any) error {
+	err := ctx.ShouldBindJSON(request)
+	if err == nil {
+		return nil
+	}
+
+	return decodeValidationErrors(err)
+}
+
+// decodeValidationErrors describes a JSON decoding error as validation errors.
+func decodeValidationErrors(err error) error_wrap.ValidationErrors {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return error_wrap.ValidationErrors{{
+			Field:   typeErr.Field,
+			Code:    error_wrap.CodeInvalidType,
+			Message: fmt.Sprintf("must be %s", typeErr.Type),
+		}}
+	}
+	return error_wrap.ValidationErrors{{Code: error_wrap.CodeInvalidJSON, Message: err.Error()}}
+}
+
+func GetAPIKeyQuota(ctx *gin.Context) (string, dto.RateLimitQuota, error) {
+	apiKey := ctx.GetString("api_key")
+	quota, isExist := ctx.Get("quota")
+	if apiKey == "" || !isExist {
+		return "", dto.RateLimitQuota{}, error_wrap.ErrIPorServiceBlocked
+	}
+
+	return apiKey, quota.(dto.RateLimitQuota), nil
+}
+
+// GetTenantScope limits the caller to its own tenant, admin keys see every tenant.
+func GetTenantScope(ctx *gin.Context) (dto.TenantScope, error) {
+	scope := dto.TenantScope{
+		TenantID:   ctx.GetString("tenant_id"),
+		AllTenants: dto.HasScope(ctx.GetStringSlice("scopes"), dto.ScopeAdmin),
+	}
+	if scope.TenantID == "" && !scope.AllTenants {
+		return dto.TenantScope{}, error_wrap.ErrForbidden
+	}
+
+	return scope, nil
+}
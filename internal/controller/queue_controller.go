@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"worker-service/internal/dto"
+	"worker-service/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AdminEmailQueuePath = "/admin/queues/email"
+)
+
+type queueController struct {
+	queueUsecase usecase.QueueUsecase
+}
+
+type QueueController interface {
+	InspectEmailQueue(ctx *gin.Context)
+}
+
+func NewQueueController(queueUsecase usecase.QueueUsecase) QueueController {
+	return &queueController{
+		queueUsecase: queueUsecase,
+	}
+}
+
+func (c *queueController) InspectEmailQueue(ctx *gin.Context) {
+	pagination := ParsePagination(ctx)
+
+	data, err := c.queueUsecase.InspectEmailQueue(ctx, pagination.Limit)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteResponseJSON(ctx, data)
+}
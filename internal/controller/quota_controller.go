@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"worker-service/internal/dto"
+	"worker-service/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	UsagePath = "/usage"
+)
+
+type quotaController struct {
+	quotaUsecase usecase.QuotaUsecase
+}
+
+type QuotaController interface {
+	GetUsage(ctx *gin.Context)
+}
+
+func NewQuotaController(quotaUsecase usecase.QuotaUsecase) QuotaController {
+	return &quotaController{
+		quotaUsecase: quotaUsecase,
+	}
+}
+
+func (c *quotaController) GetUsage(ctx *gin.Context) {
+	apiKey, quota, err := GetAPIKeyQuota(ctx)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	data, err := c.quotaUsecase.GetUsage(ctx, apiKey, quota)
+	if err != nil {
+		dto.WriteErrorResponseJSON(ctx, err)
+		return
+	}
+
+	dto.WriteRateLimitHeaders(ctx, data)
+	dto.WriteResponseJSON(ctx, data)
+}
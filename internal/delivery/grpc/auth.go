@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"worker-service/internal/delivery/grpc/pb"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/services"
+	"worker-service/internal/usecase"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// methodScopes are the scopes every method needs, the same as the REST
+// routes they mirror.
+var methodScopes = map[string]string{
+	pb.EmailService_SendEmail_FullMethodName:  dto.ScopeEmailsSend,
+	pb.EmailService_SendBulk_FullMethodName:   dto.ScopeEmailsSend,
+	pb.EmailService_GetEmail_FullMethodName:   dto.ScopeEmailsRead,
+	pb.EmailService_ListEmails_FullMethodName: dto.ScopeEmailsRead,
+	pb.EmailService_RetryEmail_FullMethodName: dto.ScopeEmailsRetry,
+}
+
+type apiKeyContextKey struct{}
+
+// apiKeyFromContext returns the api key authenticated for the call.
+func apiKeyFromContext(ctx context.Context) (dto.VerifyAPIKeyResponse, error) {
+	apiKey, isExist := ctx.Value(apiKeyContextKey{}).(dto.VerifyAPIKeyResponse)
+	if !isExist {
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrIPorServiceBlocked
+	}
+	return apiKey, nil
+}
+
+func tenantScopeFromContext(ctx context.Context) (dto.TenantScope, error) {
+	apiKey, err := apiKeyFromContext(ctx)
+	if err != nil {
+		return dto.TenantScope{}, err
+	}
+
+	scope := dto.TenantScope{
+		TenantID:   apiKey.TenantID,
+		AllTenants: dto.HasScope(apiKey.Scopes, dto.ScopeAdmin),
+	}
+	if scope.TenantID == "" && !scope.AllTenants {
+		return dto.TenantScope{}, error_wrap.ErrForbidden
+	}
+	return scope, nil
+}
+
+// authenticator checks the x-api-key metadata of every call the same way
+// the api key middleware checks the X-API-KEY header. Signed requests are not
+// supported as there is no raw body to sign.
+type authenticator struct {
+	authUsecase  usecase.AuthUsecase
+	usageTracker *services.UsageTracker
+}
+
+func (a *authenticator) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	ip := peerIP(ctx)
+
+	// Refuse clients locked out after too many failed attempts
+	if lockedFor, err := a.authUsecase.CheckLockout(ctx, ip); err != nil {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(lockedFor.Seconds())))))
+		return ctx, err
+	}
+
+	res, err := a.authUsecase.Authenticate(ctx, ip, dto.Credentials{APIKey: metadataValue(ctx, "x-api-key")})
+	if err != nil {
+		return ctx, err
+	}
+
+	scope, isExist := methodScopes[fullMethod]
+	if !isExist || !dto.HasScope(res.Scopes, scope) {
+		logrus.Errorf("error api key is missing scope %s", scope)
+		return ctx, error_wrap.ErrForbidden
+	}
+
+	a.usageTracker.TrackRequest(res.ID, ip)
+
+	return context.WithValue(ctx, apiKeyContextKey{}, res), nil
+}
+
+func (a *authenticator) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	log := callLogger(ctx, info.FullMethod)
+
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err == nil {
+		var resp any
+		resp, err = handler(ctx, req)
+		if err == nil {
+			log.Info("call handled")
+			return resp, nil
+		}
+	}
+
+	log.WithField("error", err.Error()).Info("call failed")
+	return nil, toStatusError(err)
+}
+
+func (a *authenticator) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	log := callLogger(stream.Context(), info.FullMethod)
+
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err == nil {
+		err = handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+		if err == nil {
+			log.Info("call handled")
+			return nil
+		}
+	}
+
+	log.WithField("error", err.Error()).Info("call failed")
+	return toStatusError(err)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// callLogger gives the call an ID, echoed in the x-request-id header like the
+// X-Request-ID of the REST API, and returns a logger for it.
+func callLogger(ctx context.Context, fullMethod string) *logrus.Entry {
+	requestID := metadataValue(ctx, "x-request-id")
+	if requestID == "" || len(requestID) > 64 {
+		requestID = ulid.Make().String()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+
+	return logrus.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"method":     fullMethod,
+		"client_ip":  peerIP(ctx),
+	})
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func peerIP(ctx context.Context) string {
+	p, isExist := peer.FromContext(ctx)
+	if !isExist || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,294 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"worker-service/internal/delivery/grpc/pb"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/usecase"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxSendWait caps how long SendEmail may wait for the SMTP result, as on
+	// the REST API.
+	maxSendWait = 30 * time.Second
+	// maxBulkEmails caps the emails of one SendBulk stream, which are held in
+	// memory until the stream is closed.
+	maxBulkEmails = 1000
+)
+
+type emailServer struct {
+	pb.UnimplementedEmailServiceServer
+	emailUsecase usecase.EmailUsecase
+}
+
+func NewEmailServer(emailUsecase usecase.EmailUsecase) pb.EmailServiceServer {
+	return &emailServer{
+		emailUsecase: emailUsecase,
+	}
+}
+
+func (s *emailServer) SendEmail(ctx context.Context, req *pb.SendEmailRequest) (*pb.SendEmailResponse, error) {
+	email := fromPbEmailTask(req.GetEmail())
+	if errs := email.Validate(); len(errs) > 0 {
+		return nil, error_wrap.ValidationErrors(errs)
+	}
+
+	wait := req.GetWait().AsDuration()
+	if wait < 0 || wait > maxSendWait {
+		return nil, error_wrap.ErrBadRequest
+	}
+
+	apiKey, err := apiKeyFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.emailUsecase.SendSingleEmail(ctx, usecase.SendSingleEmailRequest{
+		APIKey:   apiKey.APIKey,
+		ApiKeyID: apiKey.ID,
+		TenantID: apiKey.TenantID,
+		Quota:    apiKey.Quota,
+		Email:    email,
+		Wait:     wait,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SendEmailResponse{
+		Id:           resp.ID,
+		MessageId:    resp.MessageID,
+		Status:       resp.Status,
+		SmtpResponse: resp.SmtpResponse,
+		Error:        resp.Error,
+		Quota:        toPbQuota(resp.Quota),
+	}, nil
+}
+
+// SendBulk collects the streamed emails and queues them as one batch when the
+// client closes the stream. Streams of more than maxBulkEmails emails are
+// refused as soon as they go over.
+func (s *emailServer) SendBulk(stream pb.EmailService_SendBulkServer) error {
+	var (
+		emails       []dto.EmailTask
+		allowPartial bool
+	)
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(emails) == 0 {
+			allowPartial = req.GetAllowPartial()
+		}
+		if len(emails) == maxBulkEmails {
+			return error_wrap.ValidationErrors{{Code: error_wrap.CodeTooMany, Message: fmt.Sprintf("at most %d emails are allowed", maxBulkEmails)}}
+		}
+		emails = append(emails, fromPbEmailTask(req.GetEmail()))
+	}
+
+	if err := dto.ValidateEmailTasks(emails); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	apiKey, err := apiKeyFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.emailUsecase.SendEmail(ctx, usecase.SendEmailRequest{
+		APIKey:       apiKey.APIKey,
+		ApiKeyID:     apiKey.ID,
+		TenantID:     apiKey.TenantID,
+		Quota:        apiKey.Quota,
+		AllowPartial: allowPartial,
+		Emails:       emails,
+	})
+	if err != nil {
+		return err
+	}
+
+	res := &pb.SendBulkResponse{
+		BatchId:      resp.Batch.ID,
+		Queued:       resp.Queued,
+		Failed:       resp.Failed,
+		Rejected:     resp.Rejected,
+		RejectedData: toPbEmailTasks(resp.RejectedData),
+		Quota:        toPbQuota(resp.Quota),
+	}
+	for _, failed := range resp.FailedData {
+		for key, reason := range failed {
+			res.FailedData = append(res.FailedData, &pb.FailedEmail{Key: key, Error: reason})
+		}
+	}
+
+	return stream.SendAndClose(res)
+}
+
+func (s *emailServer) GetEmail(ctx context.Context, req *pb.GetEmailRequest) (*pb.Email, error) {
+	if req.GetId() == "" {
+		return nil, error_wrap.ValidationErrors{{Field: "id", Code: error_wrap.CodeRequired, Message: "id is required"}}
+	}
+
+	scope, err := tenantScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.emailUsecase.ListEmail(ctx, usecase.ListEmailRequestQuery{
+		TenantScope: scope,
+		Limit:       1,
+		ID:          req.GetId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(data.List) == 0 {
+		return nil, error_wrap.ErrNotFound
+	}
+
+	return toPbEmail(data.List[0]), nil
+}
+
+func (s *emailServer) ListEmails(ctx context.Context, req *pb.ListEmailsRequest) (*pb.ListEmailsResponse, error) {
+	scope, err := tenantScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.emailUsecase.ListEmail(ctx, usecase.ListEmailRequestQuery{
+		TenantScope:  scope,
+		StartAt:      fromPbTime(req.GetCreatedFrom()),
+		EndAt:        fromPbTime(req.GetCreatedTo()),
+		UpdatedFrom:  fromPbTime(req.GetUpdatedFrom()),
+		UpdatedTo:    fromPbTime(req.GetUpdatedTo()),
+		Cursor:       req.GetCursor(),
+		Limit:        usecase.PageLimit(int(req.GetLimit())),
+		IncludeTotal: req.GetIncludeTotal(),
+		Sort:         req.GetSort(),
+		IsAscending:  req.GetIsAscending(),
+		Status:       req.GetStatus(),
+		To:           req.GetTo(),
+		From:         req.GetFrom(),
+		Subject:      req.GetSubject(),
+		SubjectMatch: req.GetSubjectMatch(),
+		Search:       req.GetQ(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.ListEmailsResponse{
+		PerPage:    data.Header.PerPage,
+		NextCursor: data.Header.NextCursor,
+		PrevCursor: data.Header.PrevCursor,
+		Total:      data.Header.TotalData,
+	}
+	for _, email := range data.List {
+		res.Emails = append(res.Emails, toPbEmail(email))
+	}
+
+	return res, nil
+}
+
+func (s *emailServer) RetryEmail(ctx context.Context, req *pb.RetryEmailRequest) (*pb.RetryEmailResponse, error) {
+	if errs := (dto.RetryEmailRequest{ID: req.GetId()}).Validate(); len(errs) > 0 {
+		return nil, error_wrap.ValidationErrors(errs)
+	}
+
+	scope, err := tenantScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := apiKeyFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.emailUsecase.RetryEmail(ctx, usecase.RetryEmailRequest{
+		TenantScope: scope,
+		APIKey:      apiKey.APIKey,
+		Quota:       apiKey.Quota,
+		ID:          req.GetId(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &pb.RetryEmailResponse{}, nil
+}
+
+func fromPbEmailTask(task *pb.EmailTask) dto.EmailTask {
+	return dto.EmailTask{
+		From:    task.GetFrom(),
+		To:      task.GetTo(),
+		Subject: task.GetSubject(),
+		Body:    task.GetBody(),
+	}
+}
+
+func toPbEmailTasks(tasks []dto.EmailTask) []*pb.EmailTask {
+	res := make([]*pb.EmailTask, 0, len(tasks))
+	for _, task := range tasks {
+		res = append(res, &pb.EmailTask{
+			From:    task.From,
+			To:      task.To,
+			Subject: task.Subject,
+			Body:    task.Body,
+		})
+	}
+	return res
+}
+
+func toPbEmail(email dto.EmailHistory) *pb.Email {
+	res := &pb.Email{
+		Id:        email.ID,
+		CreatedAt: timestamppb.New(email.CreatedAt),
+		TenantId:  email.TenantID,
+		ApiKeyId:  email.ApiKeyID,
+		MessageId: email.MessageID,
+		BatchId:   email.BatchID,
+		From:      email.From,
+		To:        email.To,
+		Subject:   email.Subject,
+		Body:      email.Body,
+		Status:    dto.EmailHistoryStatusToString[dto.EmailHistoryStatus(email.Status)],
+		IsActive:  email.IsActive,
+	}
+	if email.UpdatedAt != nil {
+		res.UpdatedAt = timestamppb.New(*email.UpdatedAt)
+	}
+	return res
+}
+
+// fromPbTime returns the zero time for unset timestamps, which the filters ignore.
+func fromPbTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func toPbQuota(quota dto.QuotaUsage) *pb.Quota {
+	return &pb.Quota{
+		Limit:       int64(quota.Limit),
+		Remaining:   int64(quota.Remaining),
+		ResetAfter:  int64(quota.ResetAfter),
+		RetryAfter:  int64(quota.RetryAfter),
+		HourlyLimit: int64(quota.HourlyLimit),
+		HourlyUsed:  int64(quota.HourlyUsed),
+		DailyLimit:  int64(quota.DailyLimit),
+		DailyUsed:   int64(quota.DailyUsed),
+	}
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"worker-service/internal/pkg/error_wrap"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const errorDomain = "email-service"
+
+// errorCodes maps every general error to its gRPC code.
+var errorCodes = map[error]codes.Code{
+	error_wrap.ErrInternalServerError: codes.Internal,
+	error_wrap.ErrSqlError:            codes.Internal,
+	error_wrap.ErrTooManyRequests:     codes.ResourceExhausted,
+	error_wrap.ErrUnauthorized:        codes.Unauthenticated,
+	error_wrap.ErrInvalidToken:        codes.Unauthenticated,
+	error_wrap.ErrApiKeyIsMissing:     codes.Unauthenticated,
+	error_wrap.ErrApiKeyIsInvalid:     codes.Unauthenticated,
+	error_wrap.ErrForbidden:           codes.PermissionDenied,
+	error_wrap.ErrBadRequest:          codes.InvalidArgument,
+	error_wrap.ErrNotFound:            codes.NotFound,
+	error_wrap.ErrIPorServiceBlocked:  codes.PermissionDenied,
+	error_wrap.ErrSignatureIsInvalid:  codes.Unauthenticated,
+}
+
+// toStatusError converts err to a gRPC status carrying the stable error code
+// of the REST API as ErrorInfo reason, and the invalid fields of validation
+// errors as BadRequest details.
+func toStatusError(err error) error {
+	if _, isStatus := status.FromError(err); isStatus {
+		return err
+	}
+
+	generalErr := error_wrap.General(err)
+	message := err.Error()
+	if generalErr == nil {
+		generalErr = error_wrap.ErrInternalServerError
+		message = generalErr.Error()
+	}
+
+	st := status.New(errorCodes[generalErr], message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: error_wrap.Code(generalErr),
+		Domain: errorDomain,
+	}}
+
+	var validationErrs error_wrap.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field
+			if fieldErr.Index != nil {
+				field = fmt.Sprintf("emails[%d].%s", *fieldErr.Index, fieldErr.Field)
+			}
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Reason:      fieldErr.Code,
+				Description: fieldErr.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
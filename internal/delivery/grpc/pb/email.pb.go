@@ -0,0 +1,1229 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: email.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EmailTask struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// Comma separated recipients.
+	To            string `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Subject       string `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	Body          string `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmailTask) Reset() {
+	*x = EmailTask{}
+	mi := &file_email_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmailTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmailTask) ProtoMessage() {}
+
+func (x *EmailTask) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmailTask.ProtoReflect.Descriptor instead.
+func (*EmailTask) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EmailTask) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *EmailTask) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *EmailTask) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *EmailTask) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+type Quota struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int64                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Remaining     int64                  `protobuf:"varint,2,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	ResetAfter    int64                  `protobuf:"varint,3,opt,name=reset_after,json=resetAfter,proto3" json:"reset_after,omitempty"`
+	RetryAfter    int64                  `protobuf:"varint,4,opt,name=retry_after,json=retryAfter,proto3" json:"retry_after,omitempty"`
+	HourlyLimit   int64                  `protobuf:"varint,5,opt,name=hourly_limit,json=hourlyLimit,proto3" json:"hourly_limit,omitempty"`
+	HourlyUsed    int64                  `protobuf:"varint,6,opt,name=hourly_used,json=hourlyUsed,proto3" json:"hourly_used,omitempty"`
+	DailyLimit    int64                  `protobuf:"varint,7,opt,name=daily_limit,json=dailyLimit,proto3" json:"daily_limit,omitempty"`
+	DailyUsed     int64                  `protobuf:"varint,8,opt,name=daily_used,json=dailyUsed,proto3" json:"daily_used,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quota) Reset() {
+	*x = Quota{}
+	mi := &file_email_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quota) ProtoMessage() {}
+
+func (x *Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quota.ProtoReflect.Descriptor instead.
+func (*Quota) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Quota) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *Quota) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *Quota) GetResetAfter() int64 {
+	if x != nil {
+		return x.ResetAfter
+	}
+	return 0
+}
+
+func (x *Quota) GetRetryAfter() int64 {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return 0
+}
+
+func (x *Quota) GetHourlyLimit() int64 {
+	if x != nil {
+		return x.HourlyLimit
+	}
+	return 0
+}
+
+func (x *Quota) GetHourlyUsed() int64 {
+	if x != nil {
+		return x.HourlyUsed
+	}
+	return 0
+}
+
+func (x *Quota) GetDailyLimit() int64 {
+	if x != nil {
+		return x.DailyLimit
+	}
+	return 0
+}
+
+func (x *Quota) GetDailyUsed() int64 {
+	if x != nil {
+		return x.DailyUsed
+	}
+	return 0
+}
+
+type SendEmailRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email *EmailTask             `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// How long to wait for the SMTP result, unset queues the email.
+	Wait          *durationpb.Duration `protobuf:"bytes,2,opt,name=wait,proto3" json:"wait,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendEmailRequest) Reset() {
+	*x = SendEmailRequest{}
+	mi := &file_email_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendEmailRequest) ProtoMessage() {}
+
+func (x *SendEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendEmailRequest.ProtoReflect.Descriptor instead.
+func (*SendEmailRequest) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SendEmailRequest) GetEmail() *EmailTask {
+	if x != nil {
+		return x.Email
+	}
+	return nil
+}
+
+func (x *SendEmailRequest) GetWait() *durationpb.Duration {
+	if x != nil {
+		return x.Wait
+	}
+	return nil
+}
+
+type SendEmailResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MessageId string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	// QUEUED until the email is attempted.
+	Status        string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	SmtpResponse  string `protobuf:"bytes,4,opt,name=smtp_response,json=smtpResponse,proto3" json:"smtp_response,omitempty"`
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Quota         *Quota `protobuf:"bytes,6,opt,name=quota,proto3" json:"quota,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendEmailResponse) Reset() {
+	*x = SendEmailResponse{}
+	mi := &file_email_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendEmailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendEmailResponse) ProtoMessage() {}
+
+func (x *SendEmailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendEmailResponse.ProtoReflect.Descriptor instead.
+func (*SendEmailResponse) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SendEmailResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SendEmailResponse) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *SendEmailResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SendEmailResponse) GetSmtpResponse() string {
+	if x != nil {
+		return x.SmtpResponse
+	}
+	return ""
+}
+
+func (x *SendEmailResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *SendEmailResponse) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+type SendBulkRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email *EmailTask             `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// Send the emails that fit the quota and reject the rest, only read from
+	// the first message.
+	AllowPartial  bool `protobuf:"varint,2,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendBulkRequest) Reset() {
+	*x = SendBulkRequest{}
+	mi := &file_email_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendBulkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendBulkRequest) ProtoMessage() {}
+
+func (x *SendBulkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendBulkRequest.ProtoReflect.Descriptor instead.
+func (*SendBulkRequest) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SendBulkRequest) GetEmail() *EmailTask {
+	if x != nil {
+		return x.Email
+	}
+	return nil
+}
+
+func (x *SendBulkRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+type FailedEmail struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Recipients and subject of the email.
+	Key           string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FailedEmail) Reset() {
+	*x = FailedEmail{}
+	mi := &file_email_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FailedEmail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailedEmail) ProtoMessage() {}
+
+func (x *FailedEmail) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailedEmail.ProtoReflect.Descriptor instead.
+func (*FailedEmail) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FailedEmail) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *FailedEmail) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SendBulkResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Failed       int64                  `protobuf:"varint,2,opt,name=failed,proto3" json:"failed,omitempty"`
+	Rejected     int64                  `protobuf:"varint,3,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	FailedData   []*FailedEmail         `protobuf:"bytes,5,rep,name=failed_data,json=failedData,proto3" json:"failed_data,omitempty"`
+	RejectedData []*EmailTask           `protobuf:"bytes,6,rep,name=rejected_data,json=rejectedData,proto3" json:"rejected_data,omitempty"`
+	Quota        *Quota                 `protobuf:"bytes,7,opt,name=quota,proto3" json:"quota,omitempty"`
+	// batch_id groups the emails of this send, see GET /batches/{id}.
+	BatchId       string `protobuf:"bytes,8,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Queued        int64  `protobuf:"varint,9,opt,name=queued,proto3" json:"queued,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendBulkResponse) Reset() {
+	*x = SendBulkResponse{}
+	mi := &file_email_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendBulkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendBulkResponse) ProtoMessage() {}
+
+func (x *SendBulkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendBulkResponse.ProtoReflect.Descriptor instead.
+func (*SendBulkResponse) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SendBulkResponse) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *SendBulkResponse) GetRejected() int64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *SendBulkResponse) GetFailedData() []*FailedEmail {
+	if x != nil {
+		return x.FailedData
+	}
+	return nil
+}
+
+func (x *SendBulkResponse) GetRejectedData() []*EmailTask {
+	if x != nil {
+		return x.RejectedData
+	}
+	return nil
+}
+
+func (x *SendBulkResponse) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *SendBulkResponse) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *SendBulkResponse) GetQueued() int64 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+type Email struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	TenantId  string                 `protobuf:"bytes,4,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ApiKeyId  string                 `protobuf:"bytes,5,opt,name=api_key_id,json=apiKeyId,proto3" json:"api_key_id,omitempty"`
+	MessageId string                 `protobuf:"bytes,6,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	From      string                 `protobuf:"bytes,7,opt,name=from,proto3" json:"from,omitempty"`
+	To        string                 `protobuf:"bytes,8,opt,name=to,proto3" json:"to,omitempty"`
+	Subject   string                 `protobuf:"bytes,9,opt,name=subject,proto3" json:"subject,omitempty"`
+	Body      string                 `protobuf:"bytes,10,opt,name=body,proto3" json:"body,omitempty"`
+	// PENDING, SUCCESS, FAILED, CANCELLED or QUEUED.
+	Status        string `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`
+	IsActive      bool   `protobuf:"varint,12,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	BatchId       string `protobuf:"bytes,13,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Email) Reset() {
+	*x = Email{}
+	mi := &file_email_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Email) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Email) ProtoMessage() {}
+
+func (x *Email) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Email.ProtoReflect.Descriptor instead.
+func (*Email) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Email) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Email) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Email) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Email) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *Email) GetApiKeyId() string {
+	if x != nil {
+		return x.ApiKeyId
+	}
+	return ""
+}
+
+func (x *Email) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *Email) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Email) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Email) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Email) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Email) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Email) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Email) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+type GetEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEmailRequest) Reset() {
+	*x = GetEmailRequest{}
+	mi := &file_email_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEmailRequest) ProtoMessage() {}
+
+func (x *GetEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEmailRequest.ProtoReflect.Descriptor instead.
+func (*GetEmailRequest) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetEmailRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListEmailsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Cursor string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Emails per page, defaults to 10 and is capped at 100.
+	Limit        int32    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	IncludeTotal bool     `protobuf:"varint,3,opt,name=include_total,json=includeTotal,proto3" json:"include_total,omitempty"`
+	Sort         string   `protobuf:"bytes,4,opt,name=sort,proto3" json:"sort,omitempty"`
+	IsAscending  bool     `protobuf:"varint,5,opt,name=is_ascending,json=isAscending,proto3" json:"is_ascending,omitempty"`
+	Status       []string `protobuf:"bytes,6,rep,name=status,proto3" json:"status,omitempty"`
+	To           string   `protobuf:"bytes,7,opt,name=to,proto3" json:"to,omitempty"`
+	From         string   `protobuf:"bytes,8,opt,name=from,proto3" json:"from,omitempty"`
+	Subject      string   `protobuf:"bytes,9,opt,name=subject,proto3" json:"subject,omitempty"`
+	SubjectMatch string   `protobuf:"bytes,10,opt,name=subject_match,json=subjectMatch,proto3" json:"subject_match,omitempty"`
+	// Web search style full text query over subject and body.
+	Q             string                 `protobuf:"bytes,11,opt,name=q,proto3" json:"q,omitempty"`
+	CreatedFrom   *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	UpdatedFrom   *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=updated_from,json=updatedFrom,proto3" json:"updated_from,omitempty"`
+	UpdatedTo     *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=updated_to,json=updatedTo,proto3" json:"updated_to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEmailsRequest) Reset() {
+	*x = ListEmailsRequest{}
+	mi := &file_email_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEmailsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEmailsRequest) ProtoMessage() {}
+
+func (x *ListEmailsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEmailsRequest.ProtoReflect.Descriptor instead.
+func (*ListEmailsRequest) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListEmailsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListEmailsRequest) GetIncludeTotal() bool {
+	if x != nil {
+		return x.IncludeTotal
+	}
+	return false
+}
+
+func (x *ListEmailsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetIsAscending() bool {
+	if x != nil {
+		return x.IsAscending
+	}
+	return false
+}
+
+func (x *ListEmailsRequest) GetStatus() []string {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *ListEmailsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetSubjectMatch() string {
+	if x != nil {
+		return x.SubjectMatch
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetQ() string {
+	if x != nil {
+		return x.Q
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListEmailsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListEmailsRequest) GetUpdatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedFrom
+	}
+	return nil
+}
+
+func (x *ListEmailsRequest) GetUpdatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedTo
+	}
+	return nil
+}
+
+type ListEmailsResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Emails     []*Email               `protobuf:"bytes,1,rep,name=emails,proto3" json:"emails,omitempty"`
+	PerPage    int64                  `protobuf:"varint,2,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"`
+	NextCursor string                 `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	PrevCursor string                 `protobuf:"bytes,4,opt,name=prev_cursor,json=prevCursor,proto3" json:"prev_cursor,omitempty"`
+	// Only set when include_total was requested.
+	Total         *int64 `protobuf:"varint,5,opt,name=total,proto3,oneof" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEmailsResponse) Reset() {
+	*x = ListEmailsResponse{}
+	mi := &file_email_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEmailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEmailsResponse) ProtoMessage() {}
+
+func (x *ListEmailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEmailsResponse.ProtoReflect.Descriptor instead.
+func (*ListEmailsResponse) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListEmailsResponse) GetEmails() []*Email {
+	if x != nil {
+		return x.Emails
+	}
+	return nil
+}
+
+func (x *ListEmailsResponse) GetPerPage() int64 {
+	if x != nil {
+		return x.PerPage
+	}
+	return 0
+}
+
+func (x *ListEmailsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *ListEmailsResponse) GetPrevCursor() string {
+	if x != nil {
+		return x.PrevCursor
+	}
+	return ""
+}
+
+func (x *ListEmailsResponse) GetTotal() int64 {
+	if x != nil && x.Total != nil {
+		return *x.Total
+	}
+	return 0
+}
+
+type RetryEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryEmailRequest) Reset() {
+	*x = RetryEmailRequest{}
+	mi := &file_email_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryEmailRequest) ProtoMessage() {}
+
+func (x *RetryEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryEmailRequest.ProtoReflect.Descriptor instead.
+func (*RetryEmailRequest) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RetryEmailRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type RetryEmailResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryEmailResponse) Reset() {
+	*x = RetryEmailResponse{}
+	mi := &file_email_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryEmailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryEmailResponse) ProtoMessage() {}
+
+func (x *RetryEmailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_email_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryEmailResponse.ProtoReflect.Descriptor instead.
+func (*RetryEmailResponse) Descriptor() ([]byte, []int) {
+	return file_email_proto_rawDescGZIP(), []int{12}
+}
+
+var File_email_proto protoreflect.FileDescriptor
+
+const file_email_proto_rawDesc = "" +
+	"\n" +
+	"\vemail.proto\x12\bemail.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"]\n" +
+	"\tEmailTask\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x18\n" +
+	"\asubject\x18\x03 \x01(\tR\asubject\x12\x12\n" +
+	"\x04body\x18\x04 \x01(\tR\x04body\"\x81\x02\n" +
+	"\x05Quota\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x03R\x05limit\x12\x1c\n" +
+	"\tremaining\x18\x02 \x01(\x03R\tremaining\x12\x1f\n" +
+	"\vreset_after\x18\x03 \x01(\x03R\n" +
+	"resetAfter\x12\x1f\n" +
+	"\vretry_after\x18\x04 \x01(\x03R\n" +
+	"retryAfter\x12!\n" +
+	"\fhourly_limit\x18\x05 \x01(\x03R\vhourlyLimit\x12\x1f\n" +
+	"\vhourly_used\x18\x06 \x01(\x03R\n" +
+	"hourlyUsed\x12\x1f\n" +
+	"\vdaily_limit\x18\a \x01(\x03R\n" +
+	"dailyLimit\x12\x1d\n" +
+	"\n" +
+	"daily_used\x18\b \x01(\x03R\tdailyUsed\"l\n" +
+	"\x10SendEmailRequest\x12)\n" +
+	"\x05email\x18\x01 \x01(\v2\x13.email.v1.EmailTaskR\x05email\x12-\n" +
+	"\x04wait\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x04wait\"\xbc\x01\n" +
+	"\x11SendEmailResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12#\n" +
+	"\rsmtp_response\x18\x04 \x01(\tR\fsmtpResponse\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12%\n" +
+	"\x05quota\x18\x06 \x01(\v2\x0f.email.v1.QuotaR\x05quota\"a\n" +
+	"\x0fSendBulkRequest\x12)\n" +
+	"\x05email\x18\x01 \x01(\v2\x13.email.v1.EmailTaskR\x05email\x12#\n" +
+	"\rallow_partial\x18\x02 \x01(\bR\fallowPartial\"5\n" +
+	"\vFailedEmail\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xb5\x02\n" +
+	"\x10SendBulkResponse\x12\x16\n" +
+	"\x06failed\x18\x02 \x01(\x03R\x06failed\x12\x1a\n" +
+	"\brejected\x18\x03 \x01(\x03R\brejected\x126\n" +
+	"\vfailed_data\x18\x05 \x03(\v2\x15.email.v1.FailedEmailR\n" +
+	"failedData\x128\n" +
+	"\rrejected_data\x18\x06 \x03(\v2\x13.email.v1.EmailTaskR\frejectedData\x12%\n" +
+	"\x05quota\x18\a \x01(\v2\x0f.email.v1.QuotaR\x05quota\x12\x19\n" +
+	"\bbatch_id\x18\b \x01(\tR\abatchId\x12\x16\n" +
+	"\x06queued\x18\t \x01(\x03R\x06queuedJ\x04\b\x01\x10\x02J\x04\b\x04\x10\x05R\asuccessR\fsuccess_data\"\x89\x03\n" +
+	"\x05Email\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1b\n" +
+	"\ttenant_id\x18\x04 \x01(\tR\btenantId\x12\x1c\n" +
+	"\n" +
+	"api_key_id\x18\x05 \x01(\tR\bapiKeyId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x06 \x01(\tR\tmessageId\x12\x12\n" +
+	"\x04from\x18\a \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\b \x01(\tR\x02to\x12\x18\n" +
+	"\asubject\x18\t \x01(\tR\asubject\x12\x12\n" +
+	"\x04body\x18\n" +
+	" \x01(\tR\x04body\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\x12\x1b\n" +
+	"\tis_active\x18\f \x01(\bR\bisActive\x12\x19\n" +
+	"\bbatch_id\x18\r \x01(\tR\abatchId\"!\n" +
+	"\x0fGetEmailRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x9a\x04\n" +
+	"\x11ListEmailsRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12#\n" +
+	"\rinclude_total\x18\x03 \x01(\bR\fincludeTotal\x12\x12\n" +
+	"\x04sort\x18\x04 \x01(\tR\x04sort\x12!\n" +
+	"\fis_ascending\x18\x05 \x01(\bR\visAscending\x12\x16\n" +
+	"\x06status\x18\x06 \x03(\tR\x06status\x12\x0e\n" +
+	"\x02to\x18\a \x01(\tR\x02to\x12\x12\n" +
+	"\x04from\x18\b \x01(\tR\x04from\x12\x18\n" +
+	"\asubject\x18\t \x01(\tR\asubject\x12#\n" +
+	"\rsubject_match\x18\n" +
+	" \x01(\tR\fsubjectMatch\x12\f\n" +
+	"\x01q\x18\v \x01(\tR\x01q\x12=\n" +
+	"\fcreated_from\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12=\n" +
+	"\fupdated_from\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\vupdatedFrom\x129\n" +
+	"\n" +
+	"updated_to\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedTo\"\xbf\x01\n" +
+	"\x12ListEmailsResponse\x12'\n" +
+	"\x06emails\x18\x01 \x03(\v2\x0f.email.v1.EmailR\x06emails\x12\x19\n" +
+	"\bper_page\x18\x02 \x01(\x03R\aperPage\x12\x1f\n" +
+	"\vnext_cursor\x18\x03 \x01(\tR\n" +
+	"nextCursor\x12\x1f\n" +
+	"\vprev_cursor\x18\x04 \x01(\tR\n" +
+	"prevCursor\x12\x19\n" +
+	"\x05total\x18\x05 \x01(\x03H\x00R\x05total\x88\x01\x01B\b\n" +
+	"\x06_total\"#\n" +
+	"\x11RetryEmailRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x14\n" +
+	"\x12RetryEmailResponse2\xe3\x02\n" +
+	"\fEmailService\x12D\n" +
+	"\tSendEmail\x12\x1a.email.v1.SendEmailRequest\x1a\x1b.email.v1.SendEmailResponse\x12C\n" +
+	"\bSendBulk\x12\x19.email.v1.SendBulkRequest\x1a\x1a.email.v1.SendBulkResponse(\x01\x126\n" +
+	"\bGetEmail\x12\x19.email.v1.GetEmailRequest\x1a\x0f.email.v1.Email\x12G\n" +
+	"\n" +
+	"ListEmails\x12\x1b.email.v1.ListEmailsRequest\x1a\x1c.email.v1.ListEmailsResponse\x12G\n" +
+	"\n" +
+	"RetryEmail\x12\x1b.email.v1.RetryEmailRequest\x1a\x1c.email.v1.RetryEmailResponseB*Z(worker-service/internal/delivery/grpc/pbb\x06proto3"
+
+var (
+	file_email_proto_rawDescOnce sync.Once
+	file_email_proto_rawDescData []byte
+)
+
+func file_email_proto_rawDescGZIP() []byte {
+	file_email_proto_rawDescOnce.Do(func() {
+		file_email_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_email_proto_rawDesc), len(file_email_proto_rawDesc)))
+	})
+	return file_email_proto_rawDescData
+}
+
+var file_email_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_email_proto_goTypes = []any{
+	(*EmailTask)(nil),             // 0: email.v1.EmailTask
+	(*Quota)(nil),                 // 1: email.v1.Quota
+	(*SendEmailRequest)(nil),      // 2: email.v1.SendEmailRequest
+	(*SendEmailResponse)(nil),     // 3: email.v1.SendEmailResponse
+	(*SendBulkRequest)(nil),       // 4: email.v1.SendBulkRequest
+	(*FailedEmail)(nil),           // 5: email.v1.FailedEmail
+	(*SendBulkResponse)(nil),      // 6: email.v1.SendBulkResponse
+	(*Email)(nil),                 // 7: email.v1.Email
+	(*GetEmailRequest)(nil),       // 8: email.v1.GetEmailRequest
+	(*ListEmailsRequest)(nil),     // 9: email.v1.ListEmailsRequest
+	(*ListEmailsResponse)(nil),    // 10: email.v1.ListEmailsResponse
+	(*RetryEmailRequest)(nil),     // 11: email.v1.RetryEmailRequest
+	(*RetryEmailResponse)(nil),    // 12: email.v1.RetryEmailResponse
+	(*durationpb.Duration)(nil),   // 13: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+}
+var file_email_proto_depIdxs = []int32{
+	0,  // 0: email.v1.SendEmailRequest.email:type_name -> email.v1.EmailTask
+	13, // 1: email.v1.SendEmailRequest.wait:type_name -> google.protobuf.Duration
+	1,  // 2: email.v1.SendEmailResponse.quota:type_name -> email.v1.Quota
+	0,  // 3: email.v1.SendBulkRequest.email:type_name -> email.v1.EmailTask
+	5,  // 4: email.v1.SendBulkResponse.failed_data:type_name -> email.v1.FailedEmail
+	0,  // 5: email.v1.SendBulkResponse.rejected_data:type_name -> email.v1.EmailTask
+	1,  // 6: email.v1.SendBulkResponse.quota:type_name -> email.v1.Quota
+	14, // 7: email.v1.Email.created_at:type_name -> google.protobuf.Timestamp
+	14, // 8: email.v1.Email.updated_at:type_name -> google.protobuf.Timestamp
+	14, // 9: email.v1.ListEmailsRequest.created_from:type_name -> google.protobuf.Timestamp
+	14, // 10: email.v1.ListEmailsRequest.created_to:type_name -> google.protobuf.Timestamp
+	14, // 11: email.v1.ListEmailsRequest.updated_from:type_name -> google.protobuf.Timestamp
+	14, // 12: email.v1.ListEmailsRequest.updated_to:type_name -> google.protobuf.Timestamp
+	7,  // 13: email.v1.ListEmailsResponse.emails:type_name -> email.v1.Email
+	2,  // 14: email.v1.EmailService.SendEmail:input_type -> email.v1.SendEmailRequest
+	4,  // 15: email.v1.EmailService.SendBulk:input_type -> email.v1.SendBulkRequest
+	8,  // 16: email.v1.EmailService.GetEmail:input_type -> email.v1.GetEmailRequest
+	9,  // 17: email.v1.EmailService.ListEmails:input_type -> email.v1.ListEmailsRequest
+	11, // 18: email.v1.EmailService.RetryEmail:input_type -> email.v1.RetryEmailRequest
+	3,  // 19: email.v1.EmailService.SendEmail:output_type -> email.v1.SendEmailResponse
+	6,  // 20: email.v1.EmailService.SendBulk:output_type -> email.v1.SendBulkResponse
+	7,  // 21: email.v1.EmailService.GetEmail:output_type -> email.v1.Email
+	10, // 22: email.v1.EmailService.ListEmails:output_type -> email.v1.ListEmailsResponse
+	12, // 23: email.v1.EmailService.RetryEmail:output_type -> email.v1.RetryEmailResponse
+	19, // [19:24] is the sub-list for method output_type
+	14, // [14:19] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_email_proto_init() }
+func file_email_proto_init() {
+	if File_email_proto != nil {
+		return
+	}
+	file_email_proto_msgTypes[10].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_email_proto_rawDesc), len(file_email_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_email_proto_goTypes,
+		DependencyIndexes: file_email_proto_depIdxs,
+		MessageInfos:      file_email_proto_msgTypes,
+	}.Build()
+	File_email_proto = out.File
+	file_email_proto_goTypes = nil
+	file_email_proto_depIdxs = nil
+}
@@ -0,0 +1,29 @@
+package grpc
+
+import (
+	"worker-service/internal/delivery"
+	"worker-service/internal/delivery/grpc/pb"
+	"worker-service/internal/dto"
+
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// InitServer builds the gRPC server backed by the same usecases as the REST API.
+func InitServer(db *gorm.DB) *grpc.Server {
+	usecases := delivery.NewUsecases(db)
+
+	auth := &authenticator{
+		authUsecase:  usecases.Auth,
+		usageTracker: usecases.UsageTracker,
+	}
+	server := grpc.NewServer(
+		// Fits the largest email that passes validation
+		grpc.MaxRecvMsgSize(2*dto.MaxEmailBodySize),
+		grpc.UnaryInterceptor(auth.unaryInterceptor),
+		grpc.StreamInterceptor(auth.streamInterceptor),
+	)
+	pb.RegisterEmailServiceServer(server, NewEmailServer(usecases.Email))
+
+	return server
+}
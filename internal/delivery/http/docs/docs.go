@@ -0,0 +1,82 @@
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+//go:embed index.html
+var index []byte
+
+func OpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPI)
+}
+
+func Index(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+}
+
+// VerifyRoutes fails when the registered routes and the operations of the
+// OpenAPI document differ, so new endpoints can not ship undocumented.
+func VerifyRoutes(routes gin.RoutesInfo) error {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPI, &spec); err != nil {
+		return fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var undocumented []string
+	for _, route := range routes {
+		operation := route.Method + " " + openAPIPath(route.Path)
+		if documented[operation] {
+			delete(documented, operation)
+			continue
+		}
+		undocumented = append(undocumented, operation)
+	}
+
+	var stale []string
+	for operation := range documented {
+		stale = append(stale, operation)
+	}
+
+	if len(undocumented) == 0 && len(stale) == 0 {
+		return nil
+	}
+	slices.Sort(undocumented)
+	slices.Sort(stale)
+	return fmt.Errorf("openapi document out of date, undocumented routes: %v, unknown documented routes: %v", undocumented, stale)
+}
+
+// openAPIPath turns gin path parameters like :id into {id}.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -2,27 +2,31 @@ package http
 
 import (
 	"fmt"
-	"net/http"
-	"time"
-	"worker-service/config"
 	"worker-service/internal/controller"
+	"worker-service/internal/delivery"
+	"worker-service/internal/delivery/http/docs"
 	"worker-service/internal/dto"
 	"worker-service/internal/middleware"
-	"worker-service/internal/pkg/redis"
-	"worker-service/internal/repository"
-	"worker-service/internal/repository/unitofwork"
+	"worker-service/internal/pkg/error_wrap"
 	"worker-service/internal/services"
-	"worker-service/internal/usecase"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type Handlers struct {
-	ApiKeyMiddleware    gin.HandlerFunc
-	RateLimitMiddleware gin.HandlerFunc
-	EmailController     controller.EmailController
+	TrustedProxies       []string
+	ApiKeyMiddleware     gin.HandlerFunc
+	AdminAuthMiddleware  gin.HandlerFunc
+	EmailController      controller.EmailController
+	QuotaController      controller.QuotaController
+	ApiKeyController     controller.ApiKeyController
+	QueueController      controller.QueueController
+	EmailJobController   controller.EmailJobController
+	EmailEventController controller.EmailEventController
+	BatchController      controller.BatchController
 }
 
 func InitRoutes(db *gorm.DB) *gin.Engine {
@@ -30,44 +34,38 @@ func InitRoutes(db *gorm.DB) *gin.Engine {
 }
 
 func initHandler(db *gorm.DB) *Handlers {
-	// Validator
-	appConfig := config.New()
-	uow := unitofwork.NewUoW(db)
-
-	// Auth
-	apiKeyRepository := repository.NewApiKeyRepository(db)
-	authUsecase := usecase.NewAuthUsecase(apiKeyRepository)
-	apiKeyMiddleware := middleware.APIKeyMiddleware(authUsecase)
-
-	// Rate Limit
-	cache := redis.NewRedisClient[services.TokenBucket](*appConfig, "rate_limit:mail_service", 1*time.Minute)
-	rateLimitService := services.NewRateLimiter(cache)
-	rateLimitMiddleware := middleware.RateLimitterMiddleware(*rateLimitService)
-
-	// Email
-	emailHistoryRepository := repository.NewEmailHistoryRepository(db)
-	emailService := services.NewEmailService(*appConfig)
-	redisClient := redis.NewRedisClient[dto.EmailTask](*appConfig, "email_queue", 0)
-	emailUsecase := usecase.NewEmailUsecase(appConfig, emailHistoryRepository, uow, emailService, redisClient)
-	emailController := controller.NewEmailController(emailUsecase)
+	usecases := delivery.NewUsecases(db)
 
 	return &Handlers{
-		EmailController:     emailController,
-		ApiKeyMiddleware:    apiKeyMiddleware,
-		RateLimitMiddleware: rateLimitMiddleware,
+		TrustedProxies:       usecases.Config.Server.TrustedProxies,
+		EmailController:      controller.NewEmailController(usecases.Email, usecases.EmailExport),
+		ApiKeyMiddleware:     middleware.APIKeyMiddleware(usecases.Auth, usecases.UsageTracker),
+		AdminAuthMiddleware:  middleware.AdminAuthMiddleware(services.NewAdminTokenVerifier(*usecases.Config)),
+		QueueController:      controller.NewQueueController(usecases.Queue),
+		ApiKeyController:     controller.NewApiKeyController(usecases.ApiKey),
+		QuotaController:      controller.NewQuotaController(usecases.Quota),
+		EmailJobController:   controller.NewEmailJobController(usecases.EmailJob),
+		EmailEventController: controller.NewEmailEventController(usecases.EmailEvent),
+		BatchController:      controller.NewBatchController(usecases.Batch),
 	}
 }
 
 func setupRoutes(handler Handlers) *gin.Engine {
-	route := gin.Default()
+	route := gin.New()
+	route.Use(middleware.RequestIDMiddleware(), middleware.LoggerMiddleware(), middleware.RecoveryMiddleware())
+
+	// Only trust X-Forwarded-For from the configured proxies
+	if err := route.SetTrustedProxies(handler.TrustedProxies); err != nil {
+		logrus.Fatal("invalid trusted proxies, err: ", err)
+	}
 
 	// CORS handler
 	route.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowCredentials: true,
 		AllowMethods:     []string{"POST", "PUT", "PATCH", "DELETE", "GET", "OPTIONS", "TRACE", "CONNECT"},
-		AllowHeaders:     []string{"Authorization", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Origin", "Content-Type", "Content-Length", "Date", "origin", "Origins", "x-requested-with", "access-control-allow-methods", "access-control-allow-credentials", "x-api-key"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Authorization", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Origin", "Content-Type", "Content-Length", "Date", "origin", "Origins", "x-requested-with", "access-control-allow-methods", "access-control-allow-credentials", "x-api-key", "x-api-key-id", "x-timestamp", "x-nonce", "x-signature", "x-request-id"},
+		ExposeHeaders:    []string{"Content-Length", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "X-Request-ID"},
 	}))
 
 	// Use panic recover middleware
@@ -76,27 +74,51 @@ func setupRoutes(handler Handlers) *gin.Engine {
 	// API group
 	api := route.Group("/api/v1")
 	api.GET("/", index)
+	api.GET(docs.OpenAPIPath, docs.OpenAPI)
+	api.GET(docs.DocsPath, docs.Index)
+
+	// Admin, authenticated with operator JWTs instead of api keys
+	admin := api.Group("", handler.AdminAuthMiddleware)
+	admin.GET(controller.AdminApiKeyPath, middleware.RoleMiddleware(dto.AdminRoleAdmin), handler.ApiKeyController.ListApiKey)
+	admin.POST(controller.AdminApiKeyPath, middleware.RoleMiddleware(dto.AdminRoleAdmin), handler.ApiKeyController.CreateApiKey)
+	admin.POST(controller.AdminApiKeyRevokePath, middleware.RoleMiddleware(dto.AdminRoleAdmin), handler.ApiKeyController.RevokeApiKey)
+	admin.POST(controller.AdminApiKeyRotatePath, middleware.RoleMiddleware(dto.AdminRoleAdmin), handler.ApiKeyController.RotateApiKey)
+	admin.GET(controller.AdminApiKeyUnusedPath, middleware.RoleMiddleware(dto.AdminRoleOperator), handler.ApiKeyController.ListUnusedApiKey)
+	admin.GET(controller.AdminApiKeyUsagePath, middleware.RoleMiddleware(dto.AdminRoleOperator), handler.ApiKeyController.GetApiKeyUsage)
+	admin.GET(controller.AdminEmailQueuePath, middleware.RoleMiddleware(dto.AdminRoleOperator), handler.QueueController.InspectEmailQueue)
 
 	// Email
 	api.Use(handler.ApiKeyMiddleware)
-	api.GET(controller.EmailPath, handler.EmailController.ListEmail)
-	api.GET(controller.EmailByIdPath, handler.EmailController.ListEmailByID)
-	api.POST(controller.EmailSendBulkPath, handler.RateLimitMiddleware, handler.EmailController.SendEmail)
-	api.POST(controller.EmailRetryPath, handler.RateLimitMiddleware, handler.EmailController.RetryEmail)
+	api.GET(controller.EmailPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.EmailController.ListEmail)
+	api.GET(controller.EmailExportPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.EmailController.ExportEmail)
+	api.GET(controller.EmailEventsPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.EmailEventController.StreamEvents)
+	api.GET(controller.EmailRawPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.EmailController.GetRawEmail)
+	api.GET(controller.EmailByIdPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.EmailController.ListEmailByID)
+	api.POST(controller.EmailPath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.EmailController.SendSingleEmail)
+	api.POST(controller.EmailSendBulkPath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.EmailController.SendEmail)
+	api.POST(controller.EmailIngestPath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.EmailController.IngestEmails)
+	api.POST(controller.EmailRetryPath, middleware.ScopeMiddleware(dto.ScopeEmailsRetry), handler.EmailController.RetryEmail)
+	api.POST(controller.EmailRetryBulkPath, middleware.ScopeMiddleware(dto.ScopeEmailsRetry), handler.EmailJobController.RetryEmails)
+	api.POST(controller.EmailCancelBulkPath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.EmailJobController.CancelEmails)
+	api.GET(controller.EmailJobByIdPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.EmailJobController.GetEmailJob)
+
+	// Batch
+	api.GET(controller.BatchByIdPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.BatchController.GetBatch)
+	api.GET(controller.BatchEmailsPath, middleware.ScopeMiddleware(dto.ScopeEmailsRead), handler.BatchController.ListBatchEmails)
+	api.POST(controller.BatchPausePath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.BatchController.PauseBatch)
+	api.POST(controller.BatchResumePath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.BatchController.ResumeBatch)
+	api.POST(controller.BatchCancelPath, middleware.ScopeMiddleware(dto.ScopeEmailsSend), handler.BatchController.CancelBatch)
+
+	// Quota
+	api.GET(controller.UsagePath, handler.QuotaController.GetUsage)
 
 	return route
 }
 
 func index(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"code":    http.StatusOK,
-		"message": "welcome to kenneth's email service",
-	})
+	dto.WriteResponseJSON(c, "welcome to kenneth's email service")
 }
 
 func noRoute(c *gin.Context) {
-	c.JSON(http.StatusNotFound, gin.H{
-		"status":  "error",
-		"message": fmt.Sprintf("Path %s", http.StatusText(http.StatusNotFound)),
-	})
+	dto.WriteErrorResponseJSON(c, fmt.Errorf("path %s: %w", c.Request.URL.Path, error_wrap.ErrNotFound))
 }
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"testing"
+	"worker-service/internal/controller"
+	"worker-service/internal/delivery/http/docs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRoutesAreDocumented fails when a route is added or removed without
+// updating the OpenAPI document.
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stub := func(*gin.Context) {}
+	route := setupRoutes(Handlers{
+		ApiKeyMiddleware:     stub,
+		AdminAuthMiddleware:  stub,
+		EmailController:      controller.NewEmailController(nil, nil),
+		QuotaController:      controller.NewQuotaController(nil),
+		ApiKeyController:     controller.NewApiKeyController(nil),
+		QueueController:      controller.NewQueueController(nil),
+		EmailJobController:   controller.NewEmailJobController(nil),
+		EmailEventController: controller.NewEmailEventController(nil),
+		BatchController:      controller.NewBatchController(nil),
+	})
+
+	if err := docs.VerifyRoutes(route.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
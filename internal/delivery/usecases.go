@@ -0,0 +1,88 @@
+package delivery
+
+import (
+	"context"
+	"worker-service/config"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/redis"
+	"worker-service/internal/repository"
+	"worker-service/internal/repository/unitofwork"
+	"worker-service/internal/services"
+	"worker-service/internal/usecase"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Usecases are what the REST and gRPC APIs serve, wired the same way for both.
+type Usecases struct {
+	Config       *config.AppConfig
+	UsageTracker *services.UsageTracker
+	Auth         usecase.AuthUsecase
+	ApiKey       usecase.ApiKeyUsecase
+	Quota        usecase.QuotaUsecase
+	Email        usecase.EmailUsecase
+	EmailExport  usecase.EmailExportUsecase
+	EmailJob     usecase.EmailJobUsecase
+	EmailEvent   usecase.EmailEventUsecase
+	Batch        usecase.BatchUsecase
+	Queue        usecase.QueueUsecase
+}
+
+// NewUsecases builds every usecase of the APIs and starts the background work
+// they rely on, the api key cache listener, the usage tracker and the email
+// job watcher.
+func NewUsecases(db *gorm.DB) *Usecases {
+	appConfig := config.New()
+	uow := unitofwork.NewUoW(db)
+
+	// Auth
+	apiKeyRepository := repository.NewApiKeyRepository(db)
+	apiKeyCache := services.NewApiKeyCache(*appConfig)
+	go apiKeyCache.Listen(context.Background())
+	rejectedIPCounter := redis.NewRedisClient[int64](*appConfig, "api_key:rejected_ip", usecase.RejectedIPCounterTTL)
+	nonces := redis.NewRedisClient[bool](*appConfig, "api_key:nonce", 2*appConfig.Auth.SignatureMaxSkew)
+	signingSecrets, err := services.NewSigningSecrets(*appConfig)
+	if err != nil {
+		logrus.Fatal("invalid signing secret key, err: ", err)
+	}
+	authUsecase := usecase.NewAuthUsecase(appConfig, apiKeyRepository, apiKeyCache, rejectedIPCounter, nonces, services.NewAuthLockout(*appConfig), signingSecrets)
+	apiKeyUsageRepository := repository.NewApiKeyUsageRepository(db)
+	usageTracker := services.NewUsageTracker(apiKeyRepository, apiKeyUsageRepository, appConfig.Usage.FlushInterval)
+	go usageTracker.Run(context.Background())
+	apiKeyUsecase := usecase.NewApiKeyUsecase(appConfig, apiKeyRepository, apiKeyUsageRepository, apiKeyCache, uow, signingSecrets)
+
+	// Rate Limit
+	cache := redis.NewRedisClient[services.TokenBucket](*appConfig, "rate_limit:mail_service", 0)
+	rateLimitService := services.NewRateLimiter(cache)
+	quotaUsecase := usecase.NewQuotaUsecase(rateLimitService)
+
+	// Email
+	emailEvents := services.NewEmailEvents(*appConfig)
+	emailHistoryRepository := repository.NewEmailHistoryRepository(db)
+	batchRepository := repository.NewBatchRepository(db)
+	emailService := services.NewEmailService(*appConfig)
+	redisClient := redis.NewRedisClient[dto.QueuedEmail](*appConfig, "email_queue", 0)
+	messageStore, err := repository.NewEmailMessageStore(appConfig.Messages.Driver, appConfig.Messages.Path, db)
+	if err != nil {
+		logrus.Fatal("failed to open message store, err: ", err)
+	}
+	emailUsecase := usecase.NewEmailUsecase(appConfig, emailHistoryRepository, emailService, redisClient, rateLimitService, messageStore, emailEvents, batchRepository)
+	emailJobRepository := repository.NewEmailJobRepository(db)
+	emailJobUsecase := usecase.NewEmailJobUsecase(emailHistoryRepository, emailJobRepository, emailService, redisClient, rateLimitService, emailEvents, batchRepository)
+	go emailJobUsecase.WatchJobs(context.Background())
+
+	return &Usecases{
+		Config:       appConfig,
+		UsageTracker: usageTracker,
+		Auth:         authUsecase,
+		ApiKey:       apiKeyUsecase,
+		Quota:        quotaUsecase,
+		Email:        emailUsecase,
+		EmailExport:  usecase.NewEmailExportUsecase(emailHistoryRepository),
+		EmailJob:     emailJobUsecase,
+		EmailEvent:   usecase.NewEmailEventUsecase(emailEvents),
+		Batch:        usecase.NewBatchUsecase(batchRepository, emailHistoryRepository, redisClient, emailUsecase, emailEvents),
+		Queue:        usecase.NewQueueUsecase(redisClient),
+	}
+}
@@ -4,20 +4,20 @@ import (
 	"context"
 	tasks "worker-service/internal/dto"
 	"worker-service/internal/pkg/redis"
-	"worker-service/internal/services"
+	"worker-service/internal/usecase"
 
 	"github.com/sirupsen/logrus"
 )
 
 type EmailWorker struct {
-	queue        *redis.RedisClient[tasks.EmailTask]
-	emailService services.EmailService
+	queue           *redis.RedisClient[tasks.QueuedEmail]
+	deliveryUsecase usecase.EmailDeliveryUsecase
 }
 
-func NewEmailWorker(q *redis.RedisClient[tasks.EmailTask], emailService services.EmailService) *EmailWorker {
+func NewEmailWorker(q *redis.RedisClient[tasks.QueuedEmail], deliveryUsecase usecase.EmailDeliveryUsecase) *EmailWorker {
 	return &EmailWorker{
-		queue:        q,
-		emailService: emailService,
+		queue:           q,
+		deliveryUsecase: deliveryUsecase,
 	}
 }
 
@@ -30,10 +30,13 @@ func (w *EmailWorker) Run(ctx context.Context) error {
 		default:
 			task, err := w.queue.Dequeue(ctx)
 			if err != nil {
-				logrus.Error("error dequeuing task: ", err)
+				// Waiting on the queue is cut short on shutdown
+				if ctx.Err() == nil {
+					logrus.Error("error dequeuing task: ", err)
+				}
 				continue
 			}
-			if err := w.emailService.SendEmail(ctx, task); err != nil {
+			if _, err := w.deliveryUsecase.DeliverEmail(ctx, task); err != nil {
 				logrus.Error("error sending email: ", err)
 				continue
 			}
@@ -0,0 +1,19 @@
+package dto
+
+import "slices"
+
+const (
+	AdminRoleOperator = "operator"
+	// AdminRoleAdmin grants every other role.
+	AdminRoleAdmin = "admin"
+)
+
+type AdminIdentity struct {
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+// HasRole reports whether the identity was granted role.
+func (a AdminIdentity) HasRole(role string) bool {
+	return slices.Contains(a.Roles, role) || slices.Contains(a.Roles, AdminRoleAdmin)
+}
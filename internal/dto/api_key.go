@@ -1,27 +1,148 @@
 package dto
 
 import (
+	"slices"
 	"time"
 
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+const (
+	ScopeEmailsSend     = "emails:send"
+	ScopeEmailsRead     = "emails:read"
+	ScopeEmailsRetry    = "emails:retry"
+	ScopeTemplatesWrite = "templates:write"
+	// ScopeAdmin grants every other scope.
+	ScopeAdmin = "admin"
+)
+
+var AvailableScopes = []string{
+	ScopeEmailsSend,
+	ScopeEmailsRead,
+	ScopeEmailsRetry,
+	ScopeTemplatesWrite,
+	ScopeAdmin,
+}
+
+// HasScope reports whether scopes grant scope.
+func HasScope(scopes []string, scope string) bool {
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
 type ApiKey struct {
 	ID           string         `gorm:"primarykey" json:"id"`
 	CreatedAt    time.Time      `gorm:"index" json:"created_at"`
 	UpdatedAt    *time.Time     `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
-	KeyHash      string         `json:"key_hash"`
+	KeyHash      string         `json:"-"`
 	Name         string         `json:"name"`
+	TenantID     string         `gorm:"index" json:"tenant_id"`
 	AllowedIPs   pq.StringArray `gorm:"type:text[]" json:"allowed_ips"`
 	MaxPerMinute int            `json:"max_per_minute"`
+	BurstLimit   int            `json:"burst_limit"`
+	MaxPerHour   int            `json:"max_per_hour"`
+	MaxPerDay    int            `json:"max_per_day"`
 	IsActive     bool           `json:"is_active"`
+	Scopes       pq.StringArray `gorm:"type:text[]" json:"scopes"`
+	ExpiresAt    *time.Time     `gorm:"index" json:"expires_at"`
+	RevokedAt    *time.Time     `json:"revoked_at"`
+	RotatedFrom  string         `json:"rotated_from"`
+	LastUsedAt   *time.Time     `json:"last_used_at"`
+	LastUsedIP   string         `json:"last_used_ip"`
+	SigningKey   string         `json:"-"` // sealed request signing secret
+}
+
+// ApiKeyUsage holds the counters of one key for one UTC day.
+type ApiKeyUsage struct {
+	ApiKeyID     string    `gorm:"primarykey" json:"api_key_id"`
+	Day          time.Time `gorm:"primarykey;type:date" json:"day"`
+	RequestCount int64     `json:"request_count"`
+	SendCount    int64     `json:"send_count"` // recipients sent to, like the quota
+}
+
+type ApiKeyUsageResponse struct {
+	ApiKey        ApiKey        `json:"api_key"`
+	TotalRequests int64         `json:"total_requests"`
+	TotalSends    int64         `json:"total_sends"`
+	Daily         []ApiKeyUsage `json:"daily"`
+}
+
+// IsUsable reports whether the key is active and not expired at now.
+func (a ApiKey) IsUsable(now time.Time) bool {
+	return a.IsActive && (a.ExpiresAt == nil || now.Before(*a.ExpiresAt))
+}
+
+type CreateApiKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	// TenantID groups keys that share email history, defaults to the key ID.
+	TenantID     string     `json:"tenant_id"`
+	AllowedIPs   []string   `json:"allowed_ips"`
+	MaxPerMinute int        `json:"max_per_minute"`
+	BurstLimit   int        `json:"burst_limit"`
+	MaxPerHour   int        `json:"max_per_hour"`
+	MaxPerDay    int        `json:"max_per_day"`
+	Scopes       []string   `json:"scopes" binding:"required"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+type RotateApiKeyRequest struct {
+	// Overlap keeps the old key valid for this long, e.g. "24h". Empty revokes it immediately.
+	Overlap string `json:"overlap"`
+}
+
+type CreateApiKeyResponse struct {
+	ApiKey ApiKey `json:"api_key"`
+	// Key is the plaintext secret, it is only returned once.
+	Key string `json:"key"`
+	// SigningSecret keys request signatures, it is only returned once.
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+// RateLimitQuota is charged per recipient. A zero PerHour or PerDay disables
+// that window, while a zero PerMinute blocks the key entirely.
+type RateLimitQuota struct {
+	PerMinute int
+	Burst     int
+	PerHour   int
+	PerDay    int
 }
 
 type VerifyAPIKeyResponse struct {
-	IsValid            bool
-	ServiceName        string
-	ThresholdRateLimit int
-	APIKey             string
+	IsValid     bool
+	ID          string
+	ServiceName string
+	TenantID    string
+	Quota       RateLimitQuota
+	APIKey      string
+	AllowedIPs  []string
+	Scopes      []string
+	ExpiresAt   *time.Time
+	SigningKey  string
+}
+
+// SignedRequest carries the parts of an HMAC signed request. The signature is
+// the hex HMAC-SHA256, keyed by the decoded signing secret of the key, of
+// "METHOD\nREQUEST_URI\nTIMESTAMP\nNONCE\nhex(SHA-256(BODY))".
+type SignedRequest struct {
+	KeyID     string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+}
+
+// Credentials are what a request authenticates with, the api key secret or,
+// when it is signed, the signed request.
+type Credentials struct {
+	APIKey string
+	Signed *SignedRequest
+}
+
+// TenantScope limits data access to one tenant unless AllTenants is set.
+type TenantScope struct {
+	TenantID   string
+	AllTenants bool
 }
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+const (
+	BatchActive    = "ACTIVE"
+	BatchPaused    = "PAUSED"
+	BatchCancelled = "CANCELLED"
+	// BatchReceiving batches are still being uploaded, their emails are sent
+	// as they arrive.
+	BatchReceiving = "RECEIVING"
+	// BatchCompleted is never stored, it is reported for active batches with
+	// nothing left queued.
+	BatchCompleted = "COMPLETED"
+)
+
+// Batch groups the emails of one bulk send. The counts follow its emails from
+// status to status, Total is how many were queued in the first place.
+type Batch struct {
+	ID        string     `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	TenantID  string     `gorm:"index" json:"tenant_id"`
+	ApiKeyID  string     `json:"api_key_id"`
+	Status    string     `json:"status"`
+	Total     int64      `json:"total"`
+	Queued    int64      `json:"queued"`
+	Succeeded int64      `json:"succeeded"`
+	Pending   int64      `json:"pending"`
+	Failed    int64      `json:"failed"`
+	Cancelled int64      `json:"cancelled"`
+}
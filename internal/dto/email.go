@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,18 +13,26 @@ const (
 	EmailHistoryPending EmailHistoryStatus = 0
 	EmailHistorySuccess EmailHistoryStatus = 1
 	EmailHistoryFailed  EmailHistoryStatus = 2
+	// EmailHistoryCancelled emails were pending and will not be retried.
+	EmailHistoryCancelled EmailHistoryStatus = 3
+	// EmailHistoryQueued emails were accepted and are waiting for their first attempt.
+	EmailHistoryQueued EmailHistoryStatus = 4
 )
 
 var EmailHistoryStatusToString = map[EmailHistoryStatus]string{
-	EmailHistoryPending: "PENDING",
-	EmailHistorySuccess: "SUCCESS",
-	EmailHistoryFailed:  "FAILED",
+	EmailHistoryPending:   "PENDING",
+	EmailHistorySuccess:   "SUCCESS",
+	EmailHistoryFailed:    "FAILED",
+	EmailHistoryCancelled: "CANCELLED",
+	EmailHistoryQueued:    "QUEUED",
 }
 
 var EmailHistoryStatusTypeSelector = map[string]EmailHistoryStatus{
-	"PENDING": EmailHistoryPending,
-	"SUCCESS": EmailHistorySuccess,
-	"FAILED":  EmailHistoryFailed,
+	"PENDING":   EmailHistoryPending,
+	"SUCCESS":   EmailHistorySuccess,
+	"FAILED":    EmailHistoryFailed,
+	"CANCELLED": EmailHistoryCancelled,
+	"QUEUED":    EmailHistoryQueued,
 }
 
 type EmailHistory struct {
@@ -31,12 +40,21 @@ type EmailHistory struct {
 	CreatedAt time.Time      `gorm:"created_at,index" json:"created_at"`
 	UpdatedAt *time.Time     `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"deleted_at,index" json:"deleted_at"`
+	TenantID  string         `gorm:"index" json:"tenant_id"`
+	ApiKeyID  string         `json:"api_key_id"`
+	MessageID string         `json:"message_id"`
+	BatchID   string         `gorm:"index" json:"batch_id,omitempty"` // groups the emails of one bulk send
 	From      string         `json:"from"`
 	To        string         `json:"to"`
 	Subject   string         `json:"subject"`
 	Body      string         `json:"body"`
 	Status    uint           `json:"status"`
 	IsActive  bool           `json:"is_active"`
+	// SearchVector indexes subject and body, it is maintained by the repository.
+	SearchVector string `gorm:"type:tsvector;index:idx_email_histories_search_vector,type:gin;<-:false;->:false" json:"-"`
+	// Rank and Snippet are only selected by full text searches.
+	Rank    float64 `gorm:"->;-:migration" json:"rank,omitempty"`
+	Snippet string  `gorm:"->;-:migration" json:"snippet,omitempty"`
 }
 
 type EmailTask struct {
@@ -46,6 +64,42 @@ type EmailTask struct {
 	Body    string `json:"body"`
 }
 
+// Recipients splits the comma separated To field into single addresses.
+func (t EmailTask) Recipients() []string {
+	var recipients []string
+	for _, to := range strings.Split(t.To, ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			recipients = append(recipients, to)
+		}
+	}
+	return recipients
+}
+
+// QueuedEmail is an email history waiting on the email queue. The Message-ID
+// is picked when queueing so it is known before the email is sent.
+type QueuedEmail struct {
+	EmailTask
+	EmailID   string `json:"email_id"`
+	MessageID string `json:"message_id"`
+}
+
+// RenderedEmail is the exact RFC 5322 message handed to the SMTP server.
+type RenderedEmail struct {
+	MessageID string
+	Raw       []byte
+	// Response is the reply of the SMTP server to the message data.
+	Response string
+}
+
+// EmailMessage stores the rendered message of an email history.
+type EmailMessage struct {
+	EmailID   string    `gorm:"primarykey" json:"email_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	MessageID string    `json:"message_id"`
+	Raw       []byte    `json:"-"`
+}
+
 type RetryEmailRequest struct {
 	ID string `json:"id"`
 }
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// EmailStatusEvent is published whenever an email moves to another status.
+// ID is the position of the event in the event stream, it is only known
+// once the event is published.
+type EmailStatusEvent struct {
+	ID        string `json:"id,omitempty"`
+	EmailID   string `json:"email_id"`
+	MessageID string `json:"message_id"`
+	BatchID   string `json:"batch_id,omitempty"`
+	TenantID  string `json:"tenant_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	// SmtpResponse is the reply of the SMTP server to a send.
+	SmtpResponse string    `json:"smtp_response,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
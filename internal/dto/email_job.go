@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+const (
+	EmailJobRetry  = "retry"
+	EmailJobCancel = "cancel"
+)
+
+const (
+	EmailJobPending   = "PENDING"
+	EmailJobRunning   = "RUNNING"
+	EmailJobCompleted = "COMPLETED"
+	EmailJobFailed    = "FAILED"
+)
+
+// EmailJob tracks a bulk retry or cancel running in the background.
+type EmailJob struct {
+	ID         string           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time        `gorm:"index" json:"created_at"`
+	UpdatedAt  *time.Time       `json:"updated_at"`
+	TenantID   string           `gorm:"index" json:"tenant_id"`
+	ApiKeyID   string           `json:"api_key_id"`
+	Kind       string           `json:"kind"`
+	Status     string           `json:"status"`
+	Filter     BulkEmailRequest `gorm:"type:jsonb;serializer:json" json:"filter"`
+	Total      int64            `json:"total"`
+	Processed  int64            `json:"processed"`
+	Succeeded  int64            `json:"succeeded"`
+	Failed     int64            `json:"failed"`
+	Error      string           `json:"error,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at"`
+}
+
+// BulkEmailRequest selects emails either by ID or with the list filters.
+// DryRun only counts the emails the job would touch.
+type BulkEmailRequest struct {
+	IDs             []string   `json:"ids"`
+	Status          []string   `json:"status"`
+	CreatedFrom     *time.Time `json:"created_from"`
+	CreatedTo       *time.Time `json:"created_to"`
+	RecipientDomain string     `json:"recipient_domain"`
+	DryRun          bool       `json:"dry_run"`
+}
+
+type BulkEmailResponse struct {
+	DryRun bool      `json:"dry_run"`
+	Total  int64     `json:"total"`
+	Job    *EmailJob `json:"job,omitempty"`
+}
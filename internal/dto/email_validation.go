@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"worker-service/internal/pkg/error_wrap"
+)
+
+// Limits of a single EmailTask. Subjects are kept to the length of one header
+// line.
+const (
+	MaxEmailRecipients    = 50
+	MaxEmailSubjectLength = 998
+	MaxEmailBodySize      = 1 << 20
+)
+
+// Validate checks every field of t and returns one error per invalid field.
+func (t EmailTask) Validate() []error_wrap.FieldError {
+	var errs []error_wrap.FieldError
+	addError := func(field string, code string, message string) {
+		errs = append(errs, error_wrap.FieldError{Field: field, Code: code, Message: message})
+	}
+
+	// Line breaks in header fields would let callers inject their own headers
+	headers := []struct{ field, value string }{{"from", t.From}, {"to", t.To}, {"subject", t.Subject}}
+	for _, header := range headers {
+		if strings.ContainsAny(header.value, "\r\n") {
+			addError(header.field, error_wrap.CodeInvalidCharacters, "must not contain line breaks")
+		}
+	}
+
+	if t.From != "" && !isEmailAddress(t.From) {
+		addError("from", error_wrap.CodeInvalidEmail, "must be an email address")
+	}
+
+	recipients := t.Recipients()
+	switch {
+	case len(recipients) == 0:
+		addError("to", error_wrap.CodeRequired, "at least one recipient is required")
+	case len(recipients) > MaxEmailRecipients:
+		addError("to", error_wrap.CodeTooMany, fmt.Sprintf("at most %d recipients are allowed", MaxEmailRecipients))
+	default:
+		for _, recipient := range recipients {
+			if !isEmailAddress(recipient) {
+				addError("to", error_wrap.CodeInvalidEmail, fmt.Sprintf("%q is not an email address", recipient))
+				break
+			}
+		}
+	}
+
+	switch {
+	case strings.TrimSpace(t.Subject) == "":
+		addError("subject", error_wrap.CodeRequired, "subject is required")
+	case len(t.Subject) > MaxEmailSubjectLength:
+		addError("subject", error_wrap.CodeTooLong, fmt.Sprintf("must be at most %d bytes", MaxEmailSubjectLength))
+	}
+
+	if len(t.Body) > MaxEmailBodySize {
+		addError("body", error_wrap.CodeTooLong, fmt.Sprintf("must be at most %d bytes", MaxEmailBodySize))
+	}
+
+	return errs
+}
+
+func (r RetryEmailRequest) Validate() []error_wrap.FieldError {
+	if strings.TrimSpace(r.ID) == "" {
+		return []error_wrap.FieldError{{Field: "id", Code: error_wrap.CodeRequired, Message: "id is required"}}
+	}
+	return nil
+}
+
+// ValidateEmailTasks validates every task of a list, errors carry the index
+// of their task.
+func ValidateEmailTasks(tasks []EmailTask) error {
+	if len(tasks) == 0 {
+		return error_wrap.ValidationErrors{{Code: error_wrap.CodeRequired, Message: "at least one email is required"}}
+	}
+
+	var errs error_wrap.ValidationErrors
+	for i, task := range tasks {
+		for _, fieldErr := range task.Validate() {
+			index := i
+			fieldErr.Index = &index
+			errs = append(errs, fieldErr)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// isEmailAddress accepts bare addresses only, display names would end up in
+// the SMTP envelope.
+func isEmailAddress(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuotaUsage struct {
+	Limit       int `json:"limit"`
+	Remaining   int `json:"remaining"`
+	ResetAfter  int `json:"reset_after"`
+	RetryAfter  int `json:"retry_after,omitempty"`
+	HourlyLimit int `json:"hourly_limit"`
+	HourlyUsed  int `json:"hourly_used"`
+	DailyLimit  int `json:"daily_limit"`
+	DailyUsed   int `json:"daily_used"`
+}
+
+func WriteRateLimitHeaders(c *gin.Context, usage QuotaUsage) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(usage.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(usage.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(usage.ResetAfter))
+	if usage.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(usage.RetryAfter))
+	}
+}
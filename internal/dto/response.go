@@ -8,65 +8,85 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// RequestIDKey is the context key of the ID given to every request.
+const RequestIDKey = "request_id"
+
 type BaseResponse struct {
-	Status  int    `json:"status"`
-	Message string `json:"message"`
-	Data    any    `json:"data,omitempty"`
+	Status int `json:"status"`
+	// Code is the stable error code, only set on errors.
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+	Data      any    `json:"data,omitempty"`
+	// Errors lists the invalid fields of a bad request.
+	Errors []error_wrap.FieldError `json:"errors,omitempty"`
+}
+
+// errorStatuses maps every general error to its HTTP status.
+var errorStatuses = map[error]int{
+	error_wrap.ErrInternalServerError: http.StatusInternalServerError,
+	error_wrap.ErrSqlError:            http.StatusInternalServerError,
+	error_wrap.ErrTooManyRequests:     http.StatusTooManyRequests,
+	error_wrap.ErrUnauthorized:        http.StatusUnauthorized,
+	error_wrap.ErrInvalidToken:        http.StatusUnauthorized,
+	error_wrap.ErrApiKeyIsMissing:     http.StatusUnauthorized,
+	error_wrap.ErrApiKeyIsInvalid:     http.StatusUnauthorized,
+	error_wrap.ErrForbidden:           http.StatusForbidden,
+	error_wrap.ErrBadRequest:          http.StatusBadRequest,
+	error_wrap.ErrNotFound:            http.StatusNotFound,
+	error_wrap.ErrIPorServiceBlocked:  http.StatusForbidden,
+	error_wrap.ErrSignatureIsInvalid:  http.StatusUnauthorized,
 }
 
-var SuccessResponse = BaseResponse{
-	Status:  http.StatusOK,
-	Message: "Success",
+func WriteResponseJSON(ctx *gin.Context, data any) {
+	writeSuccessJSON(ctx, http.StatusOK, "Success", data)
 }
 
-func WriteResponseJSON(ctx *gin.Context, data interface{}) {
-	code := http.StatusOK
-	ctx.JSON(code, data)
-	return
+// WriteAcceptedResponseJSON is written for work that carries on after the response.
+func WriteAcceptedResponseJSON(ctx *gin.Context, data any) {
+	writeSuccessJSON(ctx, http.StatusAccepted, "Accepted", data)
 }
 
+func writeSuccessJSON(ctx *gin.Context, status int, message string, data any) {
+	ctx.JSON(status, BaseResponse{
+		Status:    status,
+		Message:   message,
+		RequestID: ctx.GetString(RequestIDKey),
+		Data:      data,
+	})
+}
+
+// WriteErrorResponseJSON writes err as one of the general errors, anything
+// else is reported as an internal error without leaking its message.
 func WriteErrorResponseJSON(c *gin.Context, err error) {
-	switch {
-	case errors.Is(err, error_wrap.ErrBadRequest):
-		c.JSON(http.StatusBadRequest, BaseResponse{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
-	case errors.Is(err, error_wrap.ErrNotFound):
-		c.JSON(http.StatusNotFound, BaseResponse{
-			Status:  http.StatusNotFound,
-			Message: err.Error(),
-		})
-	case errors.Is(err, error_wrap.ErrTooManyRequests):
-		c.JSON(http.StatusTooManyRequests, BaseResponse{
-			Status:  http.StatusTooManyRequests,
-			Message: err.Error(),
-		})
-	case errors.Is(err, error_wrap.ErrForbidden), errors.Is(err, error_wrap.ErrIPorServiceBlocked):
-		c.JSON(http.StatusForbidden, BaseResponse{
-			Status:  http.StatusForbidden,
-			Message: err.Error(),
-		})
-	case errors.Is(err, error_wrap.ErrInvalidToken), errors.Is(err, error_wrap.ErrUnauthorized):
-		c.JSON(http.StatusUnauthorized, BaseResponse{
-			Status:  http.StatusUnauthorized,
-			Message: err.Error(),
-		})
-	case errors.Is(err, error_wrap.ErrApiKeyIsMissing), errors.Is(err, error_wrap.ErrApiKeyIsInvalid):
-		c.JSON(http.StatusUnauthorized, BaseResponse{
-			Status:  http.StatusUnauthorized,
-			Message: err.Error(),
-		})
-	case errors.Is(err, error_wrap.ErrSqlError), errors.Is(err, error_wrap.ErrInternalServerError):
-		c.JSON(http.StatusInternalServerError, BaseResponse{
-			Status:  http.StatusInternalServerError,
-			Message: err.Error(),
-		})
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "unexpected error",
-		})
+	// Keep the cause for the request log
+	c.Error(err)
+
+	generalErr := error_wrap.General(err)
+	message := err.Error()
+	if generalErr == nil {
+		generalErr = error_wrap.ErrInternalServerError
+		message = generalErr.Error()
+	}
+
+	var validationErrs error_wrap.ValidationErrors
+	errors.As(err, &validationErrs)
+
+	status := errorStatuses[generalErr]
+	c.JSON(status, BaseResponse{
+		Status:    status,
+		Code:      error_wrap.Code(generalErr),
+		Message:   message,
+		RequestID: c.GetString(RequestIDKey),
+		Data:      errorData(err),
+		Errors:    validationErrs,
+	})
+}
+
+func errorData(err error) any {
+	var dataErr *error_wrap.DataError
+	if errors.As(err, &dataErr) {
+		return dataErr.Data
 	}
-	return
+	return nil
 }
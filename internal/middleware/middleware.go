@@ -1,71 +1,238 @@
 package middleware
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
 	"worker-service/internal/dto"
 	"worker-service/internal/pkg/error_wrap"
 	"worker-service/internal/services"
 	"worker-service/internal/usecase"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
 	"github.com/sirupsen/logrus"
 )
 
-func APIKeyMiddleware(authUsecase usecase.AuthUsecase) gin.HandlerFunc {
+const (
+	// maxRequestIDLength bounds request IDs taken from clients or proxies.
+	maxRequestIDLength = 64
+	// maxSignedBodySize bounds the bodies of signed requests, they are read
+	// whole to be verified before the handler runs.
+	maxSignedBodySize = 32 << 20
+)
+
+// RequestIDMiddleware gives every request an ID, echoed in X-Request-ID and
+// in the response envelope. A well formed X-Request-ID from the caller is kept
+// so requests can be followed across services.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !isValidRequestID(requestID) {
+			requestID = ulid.Make().String()
+		}
+
+		c.Set(dto.RequestIDKey, requestID)
+		c.Header("X-Request-ID", requestID)
+
+		c.Next()
+	}
+}
+
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		isAllowed := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)
+		if !isAllowed {
+			return false
+		}
+	}
+	return true
+}
+
+// LoggerMiddleware logs every request with its ID, and the errors written
+// for it.
+func LoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		log := logrus.WithFields(logrus.Fields{
+			"request_id": c.GetString(dto.RequestIDKey),
+			"method":     c.Request.Method,
+			"path":       path,
+			"status":     c.Writer.Status(),
+			"latency":    time.Since(start).String(),
+			"client_ip":  c.ClientIP(),
+		})
+		if len(c.Errors) > 0 {
+			log = log.WithField("error", strings.Join(c.Errors.Errors(), "; "))
+		}
+
+		switch {
+		case c.Writer.Status() >= http.StatusInternalServerError:
+			log.Error("request failed")
+		default:
+			log.Info("request handled")
+		}
+	}
+}
+
+// RecoveryMiddleware turns panics into an internal error response. Handlers
+// aborting a response already under way with http.ErrAbortHandler are left to
+// the server, which drops the connection.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		logrus.WithField("request_id", c.GetString(dto.RequestIDKey)).Errorf("panic recovered: %v\n%s", recovered, debug.Stack())
+		dto.WriteErrorResponseJSON(c, error_wrap.ErrInternalServerError)
+		c.Abort()
+	})
+}
+
+func APIKeyMiddleware(authUsecase usecase.AuthUsecase, usageTracker *services.UsageTracker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		req := c.Request
 
-		// Verify api key
-		apiKey := req.Header.Get("X-API-KEY")
-		if apiKey == "" {
-			dto.WriteErrorResponseJSON(c, error_wrap.ErrApiKeyIsMissing)
+		// Refuse clients locked out after too many failed attempts
+		if lockedFor, err := authUsecase.CheckLockout(c, c.ClientIP()); err != nil {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedFor.Seconds()))))
+			dto.WriteErrorResponseJSON(c, err)
 			c.Abort()
 			return
 		}
 
-		res, err := authUsecase.VerifyAPIKey(c, apiKey)
-		if err != nil || !res.IsValid {
-			dto.WriteErrorResponseJSON(c, error_wrap.ErrApiKeyIsInvalid)
+		// The api key is either sent as is or used to sign the request
+		credentials := dto.Credentials{APIKey: req.Header.Get("X-API-KEY")}
+		if credentials.APIKey == "" && req.Header.Get("X-Signature") != "" {
+			signed, err := signedRequest(c.Writer, req)
+			if err != nil {
+				dto.WriteErrorResponseJSON(c, err)
+				c.Abort()
+				return
+			}
+			credentials.Signed = &signed
+		}
+
+		res, err := authUsecase.Authenticate(c, c.ClientIP(), credentials)
+		if err != nil {
+			dto.WriteErrorResponseJSON(c, err)
 			c.Abort()
 			return
 		}
 
+		usageTracker.TrackRequest(res.ID, c.ClientIP())
+
 		c.Set("api_key", res.APIKey)
-		c.Set("rate_limit", res.ThresholdRateLimit)
+		c.Set("api_key_id", res.ID)
+		c.Set("tenant_id", res.TenantID)
+		c.Set("quota", res.Quota)
+		c.Set("scopes", res.Scopes)
 
 		c.Next()
 	}
 }
 
-func RateLimitterMiddleware(rateLimitter services.RateLimitter) gin.HandlerFunc {
+// signedRequest reads the signature headers and the body of req, the body is
+// put back for the handlers. Bodies over maxSignedBodySize are refused.
+func signedRequest(w http.ResponseWriter, req *http.Request) (dto.SignedRequest, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(w, req.Body, maxSignedBodySize))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return dto.SignedRequest{}, error_wrap.ValidationErrors{{Code: error_wrap.CodeTooLong, Message: fmt.Sprintf("signed request bodies may be at most %d bytes", maxSignedBodySize)}}
+		}
+		if err != nil {
+			return dto.SignedRequest{}, error_wrap.ErrBadRequest
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return dto.SignedRequest{
+		KeyID:     req.Header.Get("X-API-KEY-ID"),
+		Timestamp: req.Header.Get("X-Timestamp"),
+		Nonce:     req.Header.Get("X-Nonce"),
+		Signature: req.Header.Get("X-Signature"),
+		Method:    req.Method,
+		Path:      req.URL.RequestURI(),
+		Body:      body,
+	}, nil
+}
+
+// AdminAuthMiddleware authenticates operators with a bearer JWT.
+func AdminAuthMiddleware(verifier *services.AdminTokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey, isExist := c.Get("api_key")
-		if !isExist {
-			logrus.Error("error service is forbidden")
-			dto.WriteErrorResponseJSON(c, error_wrap.ErrIPorServiceBlocked)
+		token, isBearer := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !isBearer || token == "" {
+			dto.WriteErrorResponseJSON(c, error_wrap.ErrUnauthorized)
 			c.Abort()
 			return
 		}
 
-		token, isTokenExist := c.Get("rate_limit")
-		if !isTokenExist {
-			logrus.Error("error token not found")
-			dto.WriteErrorResponseJSON(c, error_wrap.ErrIPorServiceBlocked)
+		identity, err := verifier.Verify(token)
+		if err != nil {
+			logrus.Error("error verifying admin token: ", err)
+			dto.WriteErrorResponseJSON(c, error_wrap.ErrInvalidToken)
 			c.Abort()
 			return
 		}
 
-		if err := rateLimitter.RefillToken(c, apiKey.(string), token.(int)); err != nil {
-			logrus.Error("error refilling token: ", err)
-			dto.WriteErrorResponseJSON(c, error_wrap.ErrIPorServiceBlocked)
+		c.Set("admin_identity", identity)
+
+		c.Next()
+	}
+}
+
+// RoleMiddleware rejects operators missing any of the required roles.
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, isExist := c.Get("admin_identity")
+		if !isExist {
+			dto.WriteErrorResponseJSON(c, error_wrap.ErrUnauthorized)
 			c.Abort()
 			return
 		}
 
-		if !rateLimitter.Allow(c, apiKey.(string)) {
-			logrus.Error("error rate limit")
-			dto.WriteErrorResponseJSON(c, error_wrap.ErrTooManyRequests)
-			c.Abort()
-			return
+		identity := value.(dto.AdminIdentity)
+		for _, role := range roles {
+			if !identity.HasRole(role) {
+				logrus.Errorf("error admin %s is missing role %s", identity.Subject, role)
+				dto.WriteErrorResponseJSON(c, error_wrap.ErrForbidden)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// ScopeMiddleware rejects api keys missing any of the required scopes.
+func ScopeMiddleware(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice("scopes")
+		for _, scope := range scopes {
+			if !dto.HasScope(granted, scope) {
+				logrus.Errorf("error api key is missing scope %s", scope)
+				dto.WriteErrorResponseJSON(c, error_wrap.ErrForbidden)
+				c.Abort()
+				return
+			}
 		}
 
 		c.Next()
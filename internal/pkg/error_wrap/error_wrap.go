@@ -14,6 +14,7 @@ var (
 	ErrBadRequest          = errors.New("bad request")
 	ErrNotFound            = errors.New("not found")
 	ErrIPorServiceBlocked  = errors.New("ip or service is blocked")
+	ErrSignatureIsInvalid  = errors.New("request signature is invalid")
 )
 
 var GeneralErrors = []error{
@@ -28,4 +29,88 @@ var GeneralErrors = []error{
 	ErrBadRequest,
 	ErrNotFound,
 	ErrIPorServiceBlocked,
+	ErrSignatureIsInvalid,
+}
+
+// errorCodes are the stable codes clients can match general errors on.
+var errorCodes = map[error]string{
+	ErrInternalServerError: "internal_error",
+	ErrSqlError:            "database_error",
+	ErrTooManyRequests:     "too_many_requests",
+	ErrUnauthorized:        "unauthorized",
+	ErrInvalidToken:        "invalid_token",
+	ErrApiKeyIsMissing:     "api_key_missing",
+	ErrApiKeyIsInvalid:     "api_key_invalid",
+	ErrForbidden:           "forbidden",
+	ErrBadRequest:          "bad_request",
+	ErrNotFound:            "not_found",
+	ErrIPorServiceBlocked:  "ip_or_service_blocked",
+	ErrSignatureIsInvalid:  "signature_invalid",
+}
+
+// General returns the general error err wraps, nil when it wraps none.
+func General(err error) error {
+	for _, generalErr := range GeneralErrors {
+		if errors.Is(err, generalErr) {
+			return generalErr
+		}
+	}
+	return nil
+}
+
+// Code returns the stable code of the general error err wraps.
+func Code(err error) string {
+	if code, isExist := errorCodes[General(err)]; isExist {
+		return code
+	}
+	return errorCodes[ErrInternalServerError]
+}
+
+// DataError attaches response data to one of the general errors.
+type DataError struct {
+	Err  error
+	Data any
+}
+
+func WithData(err error, data any) error {
+	return &DataError{Err: err, Data: data}
+}
+
+func (e *DataError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DataError) Unwrap() error {
+	return e.Err
+}
+
+// Codes of FieldError.
+const (
+	CodeRequired          = "required"
+	CodeInvalidJSON       = "invalid_json"
+	CodeInvalidType       = "invalid_type"
+	CodeInvalidEmail      = "invalid_email"
+	CodeInvalidCharacters = "invalid_characters"
+	CodeTooLong           = "too_long"
+	CodeTooMany           = "too_many"
+)
+
+// FieldError describes one invalid field of a request body. Index is the
+// position of the item in requests taking a list.
+type FieldError struct {
+	Index   *int   `json:"index,omitempty"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is a bad request listing every invalid field.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	return "validation failed"
+}
+
+func (e ValidationErrors) Unwrap() error {
+	return ErrBadRequest
 }
@@ -0,0 +1,51 @@
+package htmltext
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Strip returns the visible text of an HTML document, with entities decoded
+// and the content of script and style elements dropped. Plain text is
+// returned unchanged apart from whitespace.
+func Strip(document string) string {
+	var (
+		text      strings.Builder
+		tokenizer = html.NewTokenizer(strings.NewReader(document))
+		skip      int
+	)
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return strings.Join(strings.Fields(text.String()), " ")
+			}
+			return strings.Join(strings.Fields(document), " ")
+		case html.StartTagToken:
+			if isHidden(tokenizer) {
+				skip++
+			}
+		case html.EndTagToken:
+			if isHidden(tokenizer) && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(tokenizer.Text())
+				text.WriteByte(' ')
+			}
+		}
+	}
+}
+
+func isHidden(tokenizer *html.Tokenizer) bool {
+	name, _ := tokenizer.TagName()
+	switch string(name) {
+	case "script", "style", "head", "title":
+		return true
+	}
+	return false
+}
@@ -44,11 +44,31 @@ func (r *RedisClient[T]) Dequeue(ctx context.Context) (T, error) {
 	return task, nil
 }
 
-func (r *RedisClient[T]) Set(ctx context.Context, suffixKey string, task T) error {
-	var key string = r.key
-	if suffixKey != "" {
-		key = r.key + ":" + suffixKey
+func (r *RedisClient[T]) Len(ctx context.Context) (int64, error) {
+	return r.client.LLen(ctx, r.key).Result()
+}
+
+// Peek returns up to count queued tasks in the order they will be dequeued
+// without removing them.
+func (r *RedisClient[T]) Peek(ctx context.Context, count int64) ([]T, error) {
+	res, err := r.client.LRange(ctx, r.key, -count, -1).Result()
+	if err != nil {
+		return nil, err
 	}
+
+	tasks := make([]T, 0, len(res))
+	for i := len(res) - 1; i >= 0; i-- {
+		var task T
+		if err := json.Unmarshal([]byte(res[i]), &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (r *RedisClient[T]) Set(ctx context.Context, suffixKey string, task T) error {
+	key := r.buildKey(suffixKey)
 	data, err := json.Marshal(task)
 	if err != nil {
 		return err
@@ -58,10 +78,7 @@ func (r *RedisClient[T]) Set(ctx context.Context, suffixKey string, task T) erro
 
 func (r *RedisClient[T]) Get(ctx context.Context, suffixKey string) (T, error) {
 	var task T
-	var key string = r.key
-	if suffixKey != "" {
-		key = r.key + ":" + suffixKey
-	}
+	key := r.buildKey(suffixKey)
 	res, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		return task, err
@@ -71,3 +88,159 @@ func (r *RedisClient[T]) Get(ctx context.Context, suffixKey string) (T, error) {
 	}
 	return task, nil
 }
+
+func (r *RedisClient[T]) Del(ctx context.Context, suffixKey string) error {
+	return r.client.Del(ctx, r.buildKey(suffixKey)).Err()
+}
+
+func (r *RedisClient[T]) Publish(ctx context.Context, suffixKey string, task T) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, r.buildKey(suffixKey), data).Err()
+}
+
+// Subscribe listens to the channel of suffixKey until the subscription is
+// closed. Messages that can not be decoded into T are dropped.
+func (r *RedisClient[T]) Subscribe(ctx context.Context, suffixKey string) (*Subscription[T], error) {
+	pubsub := r.client.Subscribe(ctx, r.buildKey(suffixKey))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	sub := &Subscription[T]{
+		pubsub:   pubsub,
+		messages: make(chan T),
+		done:     make(chan struct{}),
+	}
+	go sub.forward()
+
+	return sub, nil
+}
+
+// AddToStream appends task to the stream of suffixKey, trimmed to about maxLen
+// entries, and returns the ID redis gave it.
+func (r *RedisClient[T]) AddToStream(ctx context.Context, suffixKey string, maxLen int64, task T) (string, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", err
+	}
+	return r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.buildKey(suffixKey),
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{streamDataField: data},
+	}).Result()
+}
+
+// RangeStream returns up to count entries of the stream of suffixKey added
+// after afterID, oldest first.
+func (r *RedisClient[T]) RangeStream(ctx context.Context, suffixKey string, afterID string, count int64) ([]StreamEntry[T], error) {
+	res, err := r.client.XRangeN(ctx, r.buildKey(suffixKey), "("+afterID, "+", count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]StreamEntry[T], 0, len(res))
+	for _, msg := range res {
+		data, _ := msg.Values[streamDataField].(string)
+		var task T
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, err
+		}
+		entries = append(entries, StreamEntry[T]{ID: msg.ID, Task: task})
+	}
+	return entries, nil
+}
+
+// SetNX stores task only when suffixKey does not exist yet and reports whether it did.
+func (r *RedisClient[T]) SetNX(ctx context.Context, suffixKey string, task T) (bool, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, r.buildKey(suffixKey), data, r.TTL).Result()
+}
+
+// Incr increments the counter of suffixKey and refreshes its TTL when set.
+func (r *RedisClient[T]) Incr(ctx context.Context, suffixKey string) (int64, error) {
+	key := r.buildKey(suffixKey)
+	count, err := r.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if r.TTL > 0 {
+		if err := r.client.Expire(ctx, key, r.TTL).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// TTLOf returns the remaining time to live of suffixKey, zero when it does not exist.
+func (r *RedisClient[T]) TTLOf(ctx context.Context, suffixKey string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, r.buildKey(suffixKey)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Eval runs a lua script atomically against the keys built from suffixKeys.
+func (r *RedisClient[T]) Eval(ctx context.Context, script *redis.Script, suffixKeys []string, args ...interface{}) (interface{}, error) {
+	keys := make([]string, 0, len(suffixKeys))
+	for _, suffixKey := range suffixKeys {
+		keys = append(keys, r.buildKey(suffixKey))
+	}
+	return script.Run(ctx, r.client, keys, args...).Result()
+}
+
+func (r *RedisClient[T]) buildKey(suffixKey string) string {
+	if suffixKey == "" {
+		return r.key
+	}
+	return r.key + ":" + suffixKey
+}
+
+const streamDataField = "data"
+
+// StreamEntry is a task read back from a stream along with its ID.
+type StreamEntry[T any] struct {
+	ID   string
+	Task T
+}
+
+type Subscription[T any] struct {
+	pubsub   *redis.PubSub
+	messages chan T
+	done     chan struct{}
+}
+
+func (s *Subscription[T]) Messages() <-chan T {
+	return s.messages
+}
+
+func (s *Subscription[T]) Close() error {
+	close(s.done)
+	return s.pubsub.Close()
+}
+
+func (s *Subscription[T]) forward() {
+	defer close(s.messages)
+	for msg := range s.pubsub.Channel() {
+		var task T
+		if err := json.Unmarshal([]byte(msg.Payload), &task); err != nil {
+			continue
+		}
+		select {
+		case s.messages <- task:
+		case <-s.done:
+			return
+		}
+	}
+}
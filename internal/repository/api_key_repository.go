@@ -3,9 +3,10 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
+	"time"
 	"worker-service/internal/dto"
 
+	"github.com/oklog/ulid/v2"
 	"gorm.io/gorm"
 )
 
@@ -14,7 +15,12 @@ type apiKeyRepository struct {
 }
 
 type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey *dto.ApiKey) error
+	Update(ctx context.Context, id string, apiKey *dto.ApiKey, columns ...string) error
 	FetchOne(ctx context.Context, query Query) (dto.ApiKey, error)
+	Fetch(ctx context.Context, query Query) ([]dto.ApiKey, error)
+	Count(ctx context.Context, query Query) (int64, error)
+	UpdateLastUsed(ctx context.Context, id string, at time.Time, ip string) error
 }
 
 func NewApiKeyRepository(db *gorm.DB) ApiKeyRepository {
@@ -23,6 +29,27 @@ func NewApiKeyRepository(db *gorm.DB) ApiKeyRepository {
 	}
 }
 
+func (r *apiKeyRepository) Create(ctx context.Context, data *dto.ApiKey) error {
+	if data.ID == "" {
+		data.ID = ulid.Make().String()
+	}
+	return r.db.Model(dto.ApiKey{}).WithContext(ctx).Create(data).Error
+}
+
+// Update writes columns of data, zero values included so flags such as
+// is_active can be switched off. Other columns are left alone, so the last use
+// flushed by the usage tracker meanwhile is not overwritten.
+func (r *apiKeyRepository) Update(ctx context.Context, id string, data *dto.ApiKey, columns ...string) error {
+	return r.db.Model(dto.ApiKey{}).Where("id = ?", id).WithContext(ctx).Select(columns).Updates(data).Error
+}
+
+// UpdateLastUsed never moves last_used_at backwards when flushes overlap.
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id string, at time.Time, ip string) error {
+	return r.db.Model(dto.ApiKey{}).WithContext(ctx).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at).
+		UpdateColumns(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
+
 func (r *apiKeyRepository) FetchOne(ctx context.Context, query Query) (dto.ApiKey, error) {
 	var apiKey dto.ApiKey
 	db := r.db.Model(dto.ApiKey{}).WithContext(ctx)
@@ -31,10 +58,34 @@ func (r *apiKeyRepository) FetchOne(ctx context.Context, query Query) (dto.ApiKe
 	err := db.First(&apiKey).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return dto.ApiKey{}, fmt.Errorf("data record is not found")
+			return dto.ApiKey{}, ErrRecordNotFound
 		}
 		return dto.ApiKey{}, err
 	}
 
 	return apiKey, nil
 }
+
+func (r *apiKeyRepository) Fetch(ctx context.Context, query Query) ([]dto.ApiKey, error) {
+	var apiKeys []dto.ApiKey
+	db := r.db.Model(dto.ApiKey{}).WithContext(ctx)
+	db = QueryHelperDB(db, query)
+
+	if err := db.Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) Count(ctx context.Context, query Query) (int64, error) {
+	var count int64
+	db := r.db.Model(dto.ApiKey{}).WithContext(ctx)
+	db = QueryHelperDB(db, query)
+
+	if err := db.Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"worker-service/internal/dto"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApiKeyUsageRepository interface {
+	Increment(ctx context.Context, usage dto.ApiKeyUsage) error
+	Fetch(ctx context.Context, query Query) ([]dto.ApiKeyUsage, error)
+}
+
+type apiKeyUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyUsageRepository(db *gorm.DB) ApiKeyUsageRepository {
+	return &apiKeyUsageRepository{db: db}
+}
+
+// Increment adds the counters of usage to the row of its key and day.
+func (r *apiKeyUsageRepository) Increment(ctx context.Context, usage dto.ApiKeyUsage) error {
+	return r.db.Model(dto.ApiKeyUsage{}).WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "api_key_id"}, {Name: "day"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "request_count"}, Value: gorm.Expr("api_key_usages.request_count + EXCLUDED.request_count")},
+			{Column: clause.Column{Name: "send_count"}, Value: gorm.Expr("api_key_usages.send_count + EXCLUDED.send_count")},
+		},
+	}).Create(&usage).Error
+}
+
+func (r *apiKeyUsageRepository) Fetch(ctx context.Context, query Query) ([]dto.ApiKeyUsage, error) {
+	var usages []dto.ApiKeyUsage
+	db := r.db.Model(dto.ApiKeyUsage{}).WithContext(ctx)
+	db = QueryHelperDB(db, query)
+
+	if err := db.Find(&usages).Error; err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
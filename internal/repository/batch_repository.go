@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"worker-service/internal/dto"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// batchCountColumns are the columns counting the emails of a batch per status.
+var batchCountColumns = map[dto.EmailHistoryStatus]string{
+	dto.EmailHistoryQueued:    "queued",
+	dto.EmailHistorySuccess:   "succeeded",
+	dto.EmailHistoryPending:   "pending",
+	dto.EmailHistoryFailed:    "failed",
+	dto.EmailHistoryCancelled: "cancelled",
+}
+
+type BatchRepository interface {
+	Create(ctx context.Context, batch *dto.Batch) error
+	FetchOne(ctx context.Context, query Query) (dto.Batch, error)
+	UpdateStatus(ctx context.Context, id string, from []string, status string) (bool, error)
+	AddCounts(ctx context.Context, id string, total int64, counts map[dto.EmailHistoryStatus]int64) error
+}
+
+type batchRepository struct {
+	db *gorm.DB
+}
+
+func NewBatchRepository(db *gorm.DB) BatchRepository {
+	return &batchRepository{db: db}
+}
+
+func (r *batchRepository) Create(ctx context.Context, data *dto.Batch) error {
+	if data.ID == "" {
+		data.ID = ulid.Make().String()
+	}
+	return r.db.Model(dto.Batch{}).WithContext(ctx).Create(data).Error
+}
+
+func (r *batchRepository) FetchOne(ctx context.Context, query Query) (dto.Batch, error) {
+	var batch dto.Batch
+	db := r.db.Model(dto.Batch{}).WithContext(ctx)
+	db = QueryHelperDB(db, query)
+
+	err := db.First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.Batch{}, ErrRecordNotFound
+		}
+		return dto.Batch{}, err
+	}
+
+	return batch, nil
+}
+
+// UpdateStatus moves the batch to status if it is still in one of the from
+// statuses and reports whether it did.
+func (r *batchRepository) UpdateStatus(ctx context.Context, id string, from []string, status string) (bool, error) {
+	res := r.db.Model(dto.Batch{}).WithContext(ctx).
+		Where("id = ? AND status IN (?)", id, from).
+		Update("status", status)
+	return res.RowsAffected > 0, res.Error
+}
+
+// AddCounts adds total and the count of every status in counts in place, so
+// concurrent workers never overwrite each other.
+func (r *batchRepository) AddCounts(ctx context.Context, id string, total int64, counts map[dto.EmailHistoryStatus]int64) error {
+	updates := map[string]interface{}{}
+	if total != 0 {
+		updates["total"] = gorm.Expr("total + ?", total)
+	}
+	for status, count := range counts {
+		column, isExist := batchCountColumns[status]
+		if !isExist || count == 0 {
+			continue
+		}
+		updates[column] = gorm.Expr(column+" + ?", count)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return r.db.Model(dto.Batch{}).Where("id = ?", id).WithContext(ctx).Updates(updates).Error
+}
@@ -3,19 +3,25 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
 	"worker-service/internal/dto"
+	"worker-service/internal/pkg/htmltext"
 
 	"github.com/oklog/ulid/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// SearchLanguage is the text search configuration of email histories.
+const SearchLanguage = "english"
+
 type EmailHistoryRepository interface {
 	Create(ctx context.Context, email *dto.EmailHistory) error
 	Update(ctx context.Context, id string, email *dto.EmailHistory) error
 	FetchOne(ctx context.Context, query Query) (dto.EmailHistory, error)
 	Fetch(ctx context.Context, query Query) ([]dto.EmailHistory, error)
 	Count(ctx context.Context, query Query) (int64, error)
+	Stream(ctx context.Context, query Query, fn func(email dto.EmailHistory) error) error
+	UpdateStatus(ctx context.Context, ids []string, from []dto.EmailHistoryStatus, status dto.EmailHistoryStatus) ([]string, error)
 }
 
 type emailHistoryRepository struct {
@@ -30,11 +36,52 @@ func (r *emailHistoryRepository) Create(ctx context.Context, data *dto.EmailHist
 	if data.ID == "" {
 		data.ID = ulid.Make().String()
 	}
-	return r.db.Model(dto.EmailHistory{}).WithContext(ctx).Create(data).Error
+	if err := r.db.Model(dto.EmailHistory{}).WithContext(ctx).Create(data).Error; err != nil {
+		return err
+	}
+	return r.updateSearchVector(ctx, data.ID, data.Subject, data.Body)
 }
 
 func (r *emailHistoryRepository) Update(ctx context.Context, id string, data *dto.EmailHistory) error {
-	return r.db.Model(dto.EmailHistory{}).Where("id = ?", id).WithContext(ctx).Updates(&data).Error
+	if err := r.db.Model(dto.EmailHistory{}).Where("id = ?", id).WithContext(ctx).Updates(&data).Error; err != nil {
+		return err
+	}
+	if data.Subject == "" && data.Body == "" {
+		return nil
+	}
+	return r.updateSearchVector(ctx, id, data.Subject, data.Body)
+}
+
+// UpdateStatus moves the emails of ids still in one of the from statuses to
+// status and returns the IDs of those moved. Only pending emails stay active.
+func (r *emailHistoryRepository) UpdateStatus(ctx context.Context, ids []string, from []dto.EmailHistoryStatus, status dto.EmailHistoryStatus) ([]string, error) {
+	var moved []dto.EmailHistory
+	err := r.db.Model(&moved).WithContext(ctx).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("id IN (?) AND status IN (?)", ids, from).
+		Updates(map[string]interface{}{
+			"status":    uint(status),
+			"is_active": status == dto.EmailHistoryPending,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	movedIDs := make([]string, 0, len(moved))
+	for _, email := range moved {
+		movedIDs = append(movedIDs, email.ID)
+	}
+	return movedIDs, nil
+}
+
+// updateSearchVector indexes the subject ahead of the body, whose HTML is
+// stripped so markup and styles are not searchable.
+func (r *emailHistoryRepository) updateSearchVector(ctx context.Context, id string, subject string, body string) error {
+	return r.db.Model(dto.EmailHistory{}).Where("id = ?", id).WithContext(ctx).
+		UpdateColumn("search_vector", gorm.Expr(
+			"setweight(to_tsvector(?::regconfig, ?), 'A') || setweight(to_tsvector(?::regconfig, ?), 'B')",
+			SearchLanguage, subject, SearchLanguage, htmltext.Strip(body),
+		)).Error
 }
 
 func (r *emailHistoryRepository) FetchOne(ctx context.Context, query Query) (dto.EmailHistory, error) {
@@ -45,7 +92,7 @@ func (r *emailHistoryRepository) FetchOne(ctx context.Context, query Query) (dto
 	err := db.First(&email).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return dto.EmailHistory{}, fmt.Errorf("data record is not found")
+			return dto.EmailHistory{}, ErrRecordNotFound
 		}
 		return dto.EmailHistory{}, err
 	}
@@ -76,3 +123,29 @@ func (r *emailHistoryRepository) Count(ctx context.Context, query Query) (int64,
 
 	return count, nil
 }
+
+// Stream calls fn for every matching history as rows arrive from the
+// database, so exports never hold the whole result in memory. An error from
+// fn stops the stream and is returned.
+func (r *emailHistoryRepository) Stream(ctx context.Context, query Query, fn func(email dto.EmailHistory) error) error {
+	db := r.db.Model(dto.EmailHistory{}).WithContext(ctx)
+	db = QueryHelperDB(db, query)
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var email dto.EmailHistory
+		if err := db.ScanRows(rows, &email); err != nil {
+			return err
+		}
+		if err := fn(email); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"worker-service/internal/dto"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+type EmailJobRepository interface {
+	Create(ctx context.Context, job *dto.EmailJob) error
+	Update(ctx context.Context, id string, job *dto.EmailJob) error
+	FetchOne(ctx context.Context, query Query) (dto.EmailJob, error)
+	Touch(ctx context.Context, id string) error
+	FailStale(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+type emailJobRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailJobRepository(db *gorm.DB) EmailJobRepository {
+	return &emailJobRepository{db: db}
+}
+
+func (r *emailJobRepository) Create(ctx context.Context, data *dto.EmailJob) error {
+	if data.ID == "" {
+		data.ID = ulid.Make().String()
+	}
+	return r.db.Model(dto.EmailJob{}).WithContext(ctx).Create(data).Error
+}
+
+// Update writes every column so counters can be reset to zero.
+func (r *emailJobRepository) Update(ctx context.Context, id string, data *dto.EmailJob) error {
+	return r.db.Model(dto.EmailJob{}).Where("id = ?", id).WithContext(ctx).Select("*").Omit("id", "created_at").Updates(data).Error
+}
+
+func (r *emailJobRepository) FetchOne(ctx context.Context, query Query) (dto.EmailJob, error) {
+	var job dto.EmailJob
+	db := r.db.Model(dto.EmailJob{}).WithContext(ctx)
+	db = QueryHelperDB(db, query)
+
+	err := db.First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.EmailJob{}, ErrRecordNotFound
+		}
+		return dto.EmailJob{}, err
+	}
+
+	return job, nil
+}
+
+// Touch marks the job as still running.
+func (r *emailJobRepository) Touch(ctx context.Context, id string) error {
+	return r.db.Model(dto.EmailJob{}).Where("id = ?", id).WithContext(ctx).Update("updated_at", time.Now()).Error
+}
+
+// FailStale fails the unfinished jobs last touched before before and returns
+// how many there were.
+func (r *emailJobRepository) FailStale(ctx context.Context, before time.Time, reason string) (int64, error) {
+	res := r.db.Model(dto.EmailJob{}).WithContext(ctx).
+		Where("status IN (?) AND COALESCE(updated_at, created_at) < ?", []string{dto.EmailJobPending, dto.EmailJobRunning}, before).
+		Updates(map[string]interface{}{
+			"status":      dto.EmailJobFailed,
+			"error":       reason,
+			"finished_at": time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
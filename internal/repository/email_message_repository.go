@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"worker-service/internal/dto"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	EmailMessageStorePostgres   = "postgres"
+	EmailMessageStoreFilesystem = "filesystem"
+)
+
+// EmailMessageStore keeps the rendered message of every sent email, keyed by
+// the email history ID. Putting a message again replaces it.
+type EmailMessageStore interface {
+	Put(ctx context.Context, message dto.EmailMessage) error
+	Get(ctx context.Context, emailID string) (dto.EmailMessage, error)
+}
+
+// NewEmailMessageStore opens the store selected by driver, dir is only used by
+// the filesystem store.
+func NewEmailMessageStore(driver string, dir string, db *gorm.DB) (EmailMessageStore, error) {
+	switch driver {
+	case EmailMessageStorePostgres:
+		return NewEmailMessageRepository(db), nil
+	case EmailMessageStoreFilesystem:
+		return NewFileEmailMessageStore(dir)
+	default:
+		return nil, fmt.Errorf("unknown message store %q", driver)
+	}
+}
+
+type emailMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailMessageRepository(db *gorm.DB) EmailMessageStore {
+	return &emailMessageRepository{db: db}
+}
+
+func (r *emailMessageRepository) Put(ctx context.Context, message dto.EmailMessage) error {
+	return r.db.Model(dto.EmailMessage{}).WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "message_id", "raw"}),
+	}).Create(&message).Error
+}
+
+func (r *emailMessageRepository) Get(ctx context.Context, emailID string) (dto.EmailMessage, error) {
+	var message dto.EmailMessage
+	err := r.db.Model(dto.EmailMessage{}).WithContext(ctx).Where("email_id = ?", emailID).First(&message).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.EmailMessage{}, ErrRecordNotFound
+		}
+		return dto.EmailMessage{}, err
+	}
+
+	return message, nil
+}
+
+// fileEmailMessageStore writes every message to <dir>/<email id>.eml.
+type fileEmailMessageStore struct {
+	dir string
+}
+
+func NewFileEmailMessageStore(dir string) (EmailMessageStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &fileEmailMessageStore{dir: dir}, nil
+}
+
+func (s *fileEmailMessageStore) Put(ctx context.Context, message dto.EmailMessage) error {
+	path, err := s.path(message.EmailID)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial message
+	tmp, err := os.CreateTemp(s.dir, ".message-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(message.Raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *fileEmailMessageStore) Get(ctx context.Context, emailID string) (dto.EmailMessage, error) {
+	path, err := s.path(emailID)
+	if err != nil {
+		return dto.EmailMessage{}, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return dto.EmailMessage{}, ErrRecordNotFound
+		}
+		return dto.EmailMessage{}, err
+	}
+
+	return dto.EmailMessage{EmailID: emailID, Raw: raw}, nil
+}
+
+func (s *fileEmailMessageStore) path(emailID string) (string, error) {
+	if emailID == "" || filepath.Base(emailID) != emailID {
+		return "", fmt.Errorf("invalid email id %q", emailID)
+	}
+	return filepath.Join(s.dir, emailID+".eml"), nil
+}
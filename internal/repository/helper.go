@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// ErrRecordNotFound is returned when no row matches a single row lookup.
+var ErrRecordNotFound = errors.New("data record is not found")
+
 type LockStrength string
 
 const (
@@ -24,6 +28,7 @@ type Query struct {
 	Values         []interface{}
 	Sort           string
 	Order          string
+	TieBreaker     string // unique column ordered like Sort, keeps rows sharing a sort value stable
 	SelectQuery    interface{}
 	SelectArgs     []interface{}
 	Joins          []string
@@ -141,6 +146,9 @@ func QueryHelperDB(q *gorm.DB, query Query) *gorm.DB {
 	// added logic for Sum query where we do not want it to be sorted since it will cause an error
 	if !query.RemoveSort {
 		q = q.Order(fmt.Sprintf("%s %s", query.Sort, query.Order))
+		if query.TieBreaker != "" {
+			q = q.Order(fmt.Sprintf("%s %s", query.TieBreaker, query.Order))
+		}
 	}
 
 	if query.Query == "" {
@@ -186,6 +194,16 @@ func (q *Query) AddTermCondition(term string, value interface{}) {
 	q.Values = append(q.Values, value)
 }
 
+// AddTermConditions is AddTermCondition for terms with several placeholders.
+func (q *Query) AddTermConditions(term string, values ...interface{}) {
+	if len(q.Query) > 0 {
+		term = " AND" + term
+	}
+
+	q.Query += term
+	q.Values = append(q.Values, values...)
+}
+
 func (q *Query) AddBetweenTermUsingDay(columnName string, start time.Time, days int) {
 	if len(q.Query) > 0 {
 		q.Query += " AND"
@@ -10,16 +10,22 @@ import (
 
 type uowStore struct {
 	emailHistories repository.EmailHistoryRepository
+	apiKeys        repository.ApiKeyRepository
 }
 
 type UnitOfWorkStore interface {
 	EmailHistories() repository.EmailHistoryRepository
+	ApiKeys() repository.ApiKeyRepository
 }
 
 func (u uowStore) EmailHistories() repository.EmailHistoryRepository {
 	return u.emailHistories
 }
 
+func (u uowStore) ApiKeys() repository.ApiKeyRepository {
+	return u.apiKeys
+}
+
 type unitOfWork struct {
 	db *gorm.DB
 }
@@ -38,6 +44,7 @@ func (s *unitOfWork) Do(ctx context.Context, fn UnitOfWorkBlock, option sql.TxOp
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		newStore := &uowStore{
 			emailHistories: repository.NewEmailHistoryRepository(tx),
+			apiKeys:        repository.NewApiKeyRepository(tx),
 		}
 		return fn(newStore)
 	}, &option)
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"worker-service/config"
+	"worker-service/internal/dto"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// AdminTokenVerifier validates operator JWTs signed either with the shared
+// HS256 secret or with one of the RS256 keys of the configured JWKS.
+type AdminTokenVerifier struct {
+	cfg        config.AdminConfig
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewAdminTokenVerifier(cfg config.AppConfig) *AdminTokenVerifier {
+	v := &AdminTokenVerifier{
+		cfg:        cfg.Admin,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		keys:       make(map[string]*rsa.PublicKey),
+	}
+	if v.hasJWKS() {
+		if err := v.refreshKeys(); err != nil {
+			logrus.Error("error loading admin jwks: ", err)
+		}
+	}
+	return v
+}
+
+func (v *AdminTokenVerifier) Verify(tokenString string) (dto.AdminIdentity, error) {
+	var methods []string
+	if v.cfg.JWTSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if v.hasJWKS() {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return dto.AdminIdentity{}, errors.New("admin authentication is not configured")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.cfg.AllowedLeeway),
+	}
+	if v.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if v.cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(v.cfg.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, v.keyFunc, options...); err != nil {
+		return dto.AdminIdentity{}, err
+	}
+
+	subject, _ := claims.GetSubject()
+	return dto.AdminIdentity{
+		Subject: subject,
+		Roles:   rolesFromClaims(claims, v.cfg.RoleClaim),
+	}, nil
+}
+
+func (v *AdminTokenVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return []byte(v.cfg.JWTSecret), nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		return v.publicKey(kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// publicKey looks kid up, refreshing the key set when it is stale or kid is
+// unknown so rotated keys are picked up without a restart.
+func (v *AdminTokenVerifier) publicKey(kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.lookupKey(kid)
+	stale := time.Since(v.fetchedAt) > v.cfg.JWKSRefresh
+	v.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := v.refreshKeys(); err != nil {
+		logrus.Error("error refreshing admin jwks: ", err)
+		if ok {
+			return key, nil
+		}
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *AdminTokenVerifier) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+func (v *AdminTokenVerifier) refreshKeys() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Unknown kids must not turn every request into a JWKS download
+	if time.Since(v.fetchedAt) < 10*time.Second {
+		return nil
+	}
+	v.fetchedAt = time.Now()
+
+	data, err := v.readJWKS()
+	if err != nil {
+		return err
+	}
+
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		key, err := parseRSAPublicKey(jwk)
+		if err != nil {
+			logrus.Errorf("error parsing admin jwk %s: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	v.keys = keys
+
+	return nil
+}
+
+func (v *AdminTokenVerifier) readJWKS() ([]byte, error) {
+	if v.cfg.JWKSFile != "" {
+		return os.ReadFile(v.cfg.JWKSFile)
+	}
+
+	res, err := v.httpClient.Get(v.cfg.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected jwks status %d", res.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func (v *AdminTokenVerifier) hasJWKS() bool {
+	return v.cfg.JWKSFile != "" || v.cfg.JWKSURL != ""
+}
+
+func parseRSAPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// rolesFromClaims reads the role claim, which may be a dotted path such as
+// "realm_access.roles" and hold either a single role or a list of roles.
+func rolesFromClaims(claims jwt.MapClaims, roleClaim string) []string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(roleClaim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch roles := value.(type) {
+	case string:
+		return strings.Fields(roles)
+	case []interface{}:
+		var result []string
+		for _, role := range roles {
+			if role, ok := role.(string); ok {
+				result = append(result, role)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
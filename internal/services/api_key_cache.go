@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"worker-service/config"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/lru"
+	"worker-service/internal/pkg/redis"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ApiKeyCache keeps verified api keys in process and in redis, keyed by the
+// key hash or by ApiKeyIDCacheKey. Invalidations are broadcast so every app instance drops its
+// local copy as well.
+type ApiKeyCache struct {
+	remote        *redis.RedisClient[dto.VerifyAPIKeyResponse]
+	local         *lru.Cache[string, dto.VerifyAPIKeyResponse]
+	invalidations *redis.RedisClient[string]
+}
+
+func NewApiKeyCache(cfg config.AppConfig) *ApiKeyCache {
+	return &ApiKeyCache{
+		remote:        redis.NewRedisClient[dto.VerifyAPIKeyResponse](cfg, "api_key:verified", cfg.Auth.CacheTTL),
+		local:         lru.New[string, dto.VerifyAPIKeyResponse](cfg.Auth.LocalCacheSize, cfg.Auth.LocalCacheTTL),
+		invalidations: redis.NewRedisClient[string](cfg, "api_key:invalidate", 0),
+	}
+}
+
+func (c *ApiKeyCache) Get(ctx context.Context, key string) (dto.VerifyAPIKeyResponse, bool) {
+	if res, ok := c.local.Get(key); ok {
+		return res, true
+	}
+
+	res, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return dto.VerifyAPIKeyResponse{}, false
+	}
+
+	c.local.Add(key, res)
+	return res, true
+}
+
+func (c *ApiKeyCache) Set(ctx context.Context, key string, res dto.VerifyAPIKeyResponse) {
+	c.local.Add(key, res)
+	if err := c.remote.Set(ctx, key, res); err != nil {
+		logrus.Error("error caching api key: ", err)
+	}
+}
+
+// Invalidate drops every cached entry of apiKey, by hash and by ID.
+func (c *ApiKeyCache) Invalidate(ctx context.Context, apiKey dto.ApiKey) error {
+	for _, key := range []string{apiKey.KeyHash, ApiKeyIDCacheKey(apiKey.ID)} {
+		c.local.Remove(key)
+		if err := c.remote.Del(ctx, key); err != nil {
+			return err
+		}
+		if err := c.invalidations.Publish(ctx, "", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApiKeyIDCacheKey is the cache key of a key looked up by ID, as signed requests do.
+func ApiKeyIDCacheKey(id string) string {
+	return "id:" + id
+}
+
+// Listen evicts local entries invalidated by other instances until ctx is done.
+func (c *ApiKeyCache) Listen(ctx context.Context) {
+	sub, err := c.invalidations.Subscribe(ctx, "")
+	if err != nil {
+		logrus.Error("error subscribing to api key invalidations: ", err)
+		return
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+			c.local.Remove(key)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"worker-service/config"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/redis"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	emailEventsLiveKey = "live"
+	// emailEventsReplayBatch is how many missed events are read at a time.
+	emailEventsReplayBatch = 100
+)
+
+// EmailEvents records email status changes in a capped redis stream, so
+// subscribers can catch up on what they missed, and broadcasts them over pub/sub
+// to every app instance.
+type EmailEvents struct {
+	events       *redis.RedisClient[dto.EmailStatusEvent]
+	streamLength int64
+}
+
+func NewEmailEvents(cfg config.AppConfig) *EmailEvents {
+	return &EmailEvents{
+		events:       redis.NewRedisClient[dto.EmailStatusEvent](cfg, "email_events", 0),
+		streamLength: cfg.Events.StreamLength,
+	}
+}
+
+// Publish announces event. Failing to publish does not fail the status change
+// it describes, it is only logged.
+func (e *EmailEvents) Publish(ctx context.Context, event dto.EmailStatusEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	id, err := e.events.AddToStream(ctx, "", e.streamLength, event)
+	if err != nil {
+		logrus.WithField("email_id", event.EmailID).Error("error recording email event: ", err)
+		return
+	}
+
+	event.ID = id
+	if err := e.events.Publish(ctx, emailEventsLiveKey, event); err != nil {
+		logrus.WithField("email_id", event.EmailID).Error("error publishing email event: ", err)
+	}
+}
+
+// Subscribe sends the events matching match to the returned channel until ctx
+// is done, when the channel is closed. With lastEventID the events recorded
+// after it are sent first, as far as the stream still holds them.
+func (e *EmailEvents) Subscribe(ctx context.Context, lastEventID string, match func(dto.EmailStatusEvent) bool) (<-chan dto.EmailStatusEvent, error) {
+	// Subscribe before replaying so nothing published in between is lost
+	sub, err := e.events.Subscribe(ctx, emailEventsLiveKey)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan dto.EmailStatusEvent)
+	go func() {
+		defer close(events)
+		defer sub.Close()
+
+		send := func(event dto.EmailStatusEvent) bool {
+			if !match(event) {
+				return true
+			}
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for lastEventID != "" {
+			entries, err := e.events.RangeStream(ctx, "", lastEventID, emailEventsReplayBatch)
+			if err != nil {
+				logrus.Error("error replaying email events: ", err)
+				return
+			}
+			for _, entry := range entries {
+				entry.Task.ID = entry.ID
+				if !send(entry.Task) {
+					return
+				}
+				lastEventID = entry.ID
+			}
+			if len(entries) < emailEventsReplayBatch {
+				break
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-sub.Messages():
+				if !ok {
+					return
+				}
+				// Skip what the replay already sent
+				if lastEventID != "" && !IsEventIDAfter(event.ID, lastEventID) {
+					continue
+				}
+				if !send(event) {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// IsEventIDAfter reports whether the stream ID id comes after other. Both must
+// be valid stream IDs, see IsValidEventID.
+func IsEventIDAfter(id string, other string) bool {
+	idMs, idSeq := splitEventID(id)
+	otherMs, otherSeq := splitEventID(other)
+	if idMs != otherMs {
+		return idMs > otherMs
+	}
+	return idSeq > otherSeq
+}
+
+// IsValidEventID reports whether id is a redis stream ID, "<ms>-<seq>".
+func IsValidEventID(id string) bool {
+	ms, seq, isFound := strings.Cut(id, "-")
+	if !isFound {
+		return false
+	}
+	if _, err := strconv.ParseUint(ms, 10, 64); err != nil {
+		return false
+	}
+	_, err := strconv.ParseUint(seq, 10, 64)
+	return err == nil
+}
+
+func splitEventID(id string) (uint64, uint64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	msInt, _ := strconv.ParseUint(ms, 10, 64)
+	seqInt, _ := strconv.ParseUint(seq, 10, 64)
+	return msInt, seqInt
+}
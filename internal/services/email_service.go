@@ -1,16 +1,37 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
 	"worker-service/config"
 	tasks "worker-service/internal/dto"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/gomail.v2"
 )
 
+const (
+	smtpsPort       = 465
+	smtpDialTimeout = 10 * time.Second
+)
+
 type EmailService interface {
-	SendEmail(ctx context.Context, task tasks.EmailTask) error
+	// SendEmail renders task and sends it. The rendered message is returned
+	// even when sending fails.
+	SendEmail(ctx context.Context, task tasks.EmailTask) (tasks.RenderedEmail, error)
+	// SendQueuedEmail sends a queued email with the Message-ID it was queued with.
+	SendQueuedEmail(ctx context.Context, email tasks.QueuedEmail) (tasks.RenderedEmail, error)
+	NewMessageID() string
 }
 
 type emailService struct {
@@ -23,24 +44,149 @@ func NewEmailService(cfg config.AppConfig) EmailService {
 	}
 }
 
-func (e *emailService) SendEmail(ctx context.Context, task tasks.EmailTask) error {
+func (e *emailService) SendEmail(ctx context.Context, task tasks.EmailTask) (tasks.RenderedEmail, error) {
+	return e.send(ctx, task, e.NewMessageID())
+}
+
+func (e *emailService) SendQueuedEmail(ctx context.Context, email tasks.QueuedEmail) (tasks.RenderedEmail, error) {
+	messageID := email.MessageID
+	if messageID == "" {
+		messageID = e.NewMessageID()
+	}
+	return e.send(ctx, email.EmailTask, messageID)
+}
+
+func (e *emailService) NewMessageID() string {
+	return fmt.Sprintf("<%s@%s>", ulid.Make().String(), e.messageIDDomain())
+}
+
+func (e *emailService) send(ctx context.Context, task tasks.EmailTask, messageID string) (tasks.RenderedEmail, error) {
+	rendered, err := e.render(task, messageID)
+	if err != nil {
+		return tasks.RenderedEmail{}, err
+	}
+
+	// Send the rendered bytes as is, rendering again would pick new multipart boundaries
+	rendered.Response, err = e.deliver(ctx, task.Recipients(), rendered.Raw)
+	if err != nil {
+		return rendered, err
+	}
+
+	logrus.Info("email sent successfully!")
+	return rendered, nil
+}
+
+// deliver hands raw to the SMTP server and returns its reply to the data.
+// Implicit TLS is used on port 465, STARTTLS whenever the server offers it.
+func (e *emailService) deliver(ctx context.Context, recipients []string, raw []byte) (response string, err error) {
+	addr := net.JoinHostPort(e.cfg.Smtp.Host, strconv.Itoa(e.cfg.Smtp.Port))
+	tlsConfig := &tls.Config{ServerName: e.cfg.Smtp.Host}
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	if e.cfg.Smtp.Port == smtpsPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return "", err
+	}
+	if deadline, isSet := ctx.Deadline(); isSet {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.cfg.Smtp.Host)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer client.Close()
+
+	// Rejections carry the reply of the server as well
+	defer func() {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			response = fmt.Sprintf("%d %s", protoErr.Code, protoErr.Msg)
+		}
+	}()
+
+	if isSupported, _ := client.Extension("STARTTLS"); isSupported && e.cfg.Smtp.Port != smtpsPort {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return "", err
+		}
+	}
+	if isSupported, _ := client.Extension("AUTH"); isSupported && e.cfg.Smtp.Email != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.cfg.Smtp.Email, e.cfg.Smtp.Password, e.cfg.Smtp.Host)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := client.Mail(e.cfg.Smtp.Email); err != nil {
+		return "", err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return "", err
+		}
+	}
+
+	// Go through the text connection as smtp.Client.Data drops the reply
+	id, err := client.Text.Cmd("DATA")
+	if err != nil {
+		return "", err
+	}
+	client.Text.StartResponse(id)
+	_, _, err = client.Text.ReadResponse(354)
+	client.Text.EndResponse(id)
+	if err != nil {
+		return "", err
+	}
+
+	writer := client.Text.DotWriter()
+	if _, err := writer.Write(raw); err != nil {
+		writer.Close()
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	code, message, err := client.Text.ReadResponse(250)
+	if err != nil {
+		return "", err
+	}
+	response = fmt.Sprintf("%d %s", code, message)
+
+	// The message is accepted, a failing QUIT does not change that
+	client.Quit()
+	return response, nil
+}
+
+// render builds the RFC 5322 message of task with a fixed Date and Message-ID.
+func (e *emailService) render(task tasks.EmailTask, messageID string) (tasks.RenderedEmail, error) {
 	mailer := gomail.NewMessage()
 	mailer.SetHeader("From", e.cfg.Smtp.Email)
-	mailer.SetHeader("To", task.To)
+	mailer.SetHeader("To", task.Recipients()...)
 	mailer.SetHeader("Subject", task.Subject)
+	mailer.SetHeader("Message-ID", messageID)
+	mailer.SetDateHeader("Date", time.Now())
 	mailer.SetBody("text/html", task.Body)
 
-	dialer := gomail.NewDialer(
-		e.cfg.Smtp.Host,
-		e.cfg.Smtp.Port,
-		e.cfg.Smtp.Email,
-		e.cfg.Smtp.Password,
-	)
-
-	if err := dialer.DialAndSend(mailer); err != nil {
-		return err
+	var raw bytes.Buffer
+	if _, err := mailer.WriteTo(&raw); err != nil {
+		return tasks.RenderedEmail{}, err
 	}
 
-	logrus.Info("email sent successfully!")
-	return nil
+	return tasks.RenderedEmail{
+		MessageID: messageID,
+		Raw:       raw.Bytes(),
+	}, nil
+}
+
+func (e *emailService) messageIDDomain() string {
+	if _, domain, isFound := strings.Cut(e.cfg.Smtp.Email, "@"); isFound && domain != "" {
+		return domain
+	}
+	return e.cfg.Smtp.Host
 }
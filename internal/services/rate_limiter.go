@@ -2,11 +2,135 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"time"
+	"worker-service/internal/dto"
 	"worker-service/internal/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
 )
 
+// quotaScript refills the minute bucket continuously based on the elapsed time
+// since the last call, then charges the per item costs against the bucket and
+// the hourly and daily windows in a single atomic step. Items are accepted in
+// order until one no longer fits; without partial mode nothing is charged
+// unless every item fits. Time is read from the redis server so every app
+// instance shares one clock.
+//
+// KEYS[1] bucket key
+// KEYS[2] hourly counter key
+// KEYS[3] daily counter key
+// ARGV[1] bucket capacity (burst)
+// ARGV[2] refill rate in tokens per millisecond
+// ARGV[3] hourly limit, 0 disables it
+// ARGV[4] daily limit, 0 disables it
+// ARGV[5] milliseconds until the hourly window ends
+// ARGV[6] milliseconds until the daily window ends
+// ARGV[7] partial mode, '1' or '0'
+// ARGV[8..] cost of every item
+//
+// Returns {accepted, fit, remaining, hourly_used, daily_used, retry_after_ms, reset_ms}.
+var quotaScript = goredis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local hourly_limit = tonumber(ARGV[3])
+local daily_limit = tonumber(ARGV[4])
+local hourly_ttl = tonumber(ARGV[5])
+local daily_ttl = tonumber(ARGV[6])
+local partial = ARGV[7] == '1'
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local hourly_used = tonumber(redis.call('GET', KEYS[2]) or '0')
+local daily_used = tonumber(redis.call('GET', KEYS[3]) or '0')
+
+local function available()
+	local n = math.floor(tokens)
+	if hourly_limit > 0 then
+		n = math.min(n, hourly_limit - hourly_used)
+	end
+	if daily_limit > 0 then
+		n = math.min(n, daily_limit - daily_used)
+	end
+	return math.max(0, n)
+end
+
+local items = #ARGV - 7
+local budget = available()
+local fit = 0
+local charged = 0
+local total = 0
+local next_cost = 0
+for i = 8, #ARGV do
+	local cost = tonumber(ARGV[i])
+	total = total + cost
+	if next_cost == 0 then
+		if charged + cost > budget then
+			next_cost = cost
+		else
+			charged = charged + cost
+			fit = fit + 1
+		end
+	end
+end
+
+local accepted = fit
+local needed = next_cost
+if not partial and fit < items then
+	accepted = 0
+	charged = 0
+	needed = total
+end
+
+if charged > 0 then
+	tokens = tokens - charged
+	hourly_used = redis.call('INCRBY', KEYS[2], charged)
+	redis.call('PEXPIRE', KEYS[2], hourly_ttl)
+	daily_used = redis.call('INCRBY', KEYS[3], charged)
+	redis.call('PEXPIRE', KEYS[3], daily_ttl)
+end
+
+local retry_after = 0
+if needed > 0 then
+	if needed > tokens then
+		retry_after = math.ceil((needed - tokens) / rate)
+	end
+	if hourly_limit > 0 and hourly_used + needed > hourly_limit then
+		retry_after = math.max(retry_after, hourly_ttl)
+	end
+	if daily_limit > 0 and daily_used + needed > daily_limit then
+		retry_after = math.max(retry_after, daily_ttl)
+	end
+end
+
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+
+return {accepted, fit, available(), hourly_used, daily_used, retry_after, reset}
+`)
+
 type TokenBucket struct {
-	Token int `json:"token"`
+	Requested int
+	Accepted  int
+	Fit       int
+	Usage     dto.QuotaUsage
+}
+
+// Allowed reports whether every requested item was accepted.
+func (b TokenBucket) Allowed() bool {
+	return b.Requested > 0 && b.Accepted == b.Requested
 }
 
 type RateLimitter struct {
@@ -19,38 +143,97 @@ func NewRateLimiter(cache *redis.RedisClient[TokenBucket]) *RateLimitter {
 	}
 }
 
-func (r *RateLimitter) RefillToken(ctx context.Context, key string, token int) error {
-	// Get from redis with specific key
-	if _, err := r.cache.Get(ctx, key); err != nil {
-		// If the data is empty that means we should set a new token
-		errSetCache := r.cache.Set(ctx, key, TokenBucket{
-			Token: token,
-		})
-		if errSetCache != nil {
-			return errSetCache
-		}
-		return nil
-	}
+// Take consumes cost tokens as a single item.
+func (r *RateLimitter) Take(ctx context.Context, key string, quota dto.RateLimitQuota, cost int) (TokenBucket, error) {
+	return r.Consume(ctx, key, quota, []int{cost}, false)
+}
 
-	return nil
+// Usage reports the current quota of key without charging anything.
+func (r *RateLimitter) Usage(ctx context.Context, key string, quota dto.RateLimitQuota) (dto.QuotaUsage, error) {
+	bucket, err := r.Consume(ctx, key, quota, nil, false)
+	if err != nil {
+		return dto.QuotaUsage{}, err
+	}
+	return bucket.Usage, nil
 }
 
-func (r *RateLimitter) Allow(ctx context.Context, key string) bool {
-	// Get from redis with specific key
-	data, err := r.cache.Get(ctx, key)
+// Consume charges every item cost against the quota of key. The minute bucket
+// holds at most quota.Burst tokens and refills continuously at
+// quota.PerMinute tokens per minute. With allowPartial the leading items that
+// fit are accepted, otherwise either every item is accepted or none is.
+func (r *RateLimitter) Consume(ctx context.Context, key string, quota dto.RateLimitQuota, costs []int, allowPartial bool) (TokenBucket, error) {
+	burst := quota.Burst
+	if burst <= 0 {
+		burst = quota.PerMinute
+	}
+
+	usage := dto.QuotaUsage{
+		Limit:       burst,
+		HourlyLimit: quota.PerHour,
+		DailyLimit:  quota.PerDay,
+	}
+	if quota.PerMinute <= 0 {
+		return TokenBucket{Requested: len(costs), Usage: usage}, nil
+	}
+
+	now := time.Now().UTC()
+	hourEnd := now.Truncate(time.Hour).Add(time.Hour)
+	dayEnd := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	keys := []string{
+		key,
+		fmt.Sprintf("%s:hourly:%s", key, now.Format("2006010215")),
+		fmt.Sprintf("%s:daily:%s", key, now.Format("20060102")),
+	}
+
+	partial := 0
+	if allowPartial {
+		partial = 1
+	}
+	rate := float64(quota.PerMinute) / float64(time.Minute/time.Millisecond)
+	args := []interface{}{
+		burst,
+		rate,
+		quota.PerHour,
+		quota.PerDay,
+		hourEnd.Sub(now).Milliseconds(),
+		dayEnd.Sub(now).Milliseconds(),
+		partial,
+	}
+	for _, cost := range costs {
+		args = append(args, cost)
+	}
+
+	res, err := r.cache.Eval(ctx, quotaScript, keys, args...)
 	if err != nil {
-		return false
+		return TokenBucket{}, err
 	}
 
-	// Update token
-	if data.Token > 0 {
-		data.Token--
-		err := r.cache.Set(ctx, key, data)
-		if err != nil {
-			return false
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 7 {
+		return TokenBucket{}, fmt.Errorf("unexpected quota result: %v", res)
+	}
+	result := make([]int64, len(values))
+	for i, value := range values {
+		if result[i], ok = value.(int64); !ok {
+			return TokenBucket{}, fmt.Errorf("unexpected quota result: %v", res)
 		}
-		return true
 	}
 
-	return false
+	usage.Remaining = int(result[2])
+	usage.HourlyUsed = int(result[3])
+	usage.DailyUsed = int(result[4])
+	usage.RetryAfter = millisecondsToSeconds(result[5])
+	usage.ResetAfter = millisecondsToSeconds(result[6])
+
+	return TokenBucket{
+		Requested: len(costs),
+		Accepted:  int(result[0]),
+		Fit:       int(result[1]),
+		Usage:     usage,
+	}, nil
+}
+
+// millisecondsToSeconds rounds up so clients never retry before the quota refills.
+func millisecondsToSeconds(ms int64) int {
+	return int(math.Ceil(float64(ms) / 1000))
 }
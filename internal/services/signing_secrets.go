@@ -0,0 +1,93 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"worker-service/config"
+)
+
+const (
+	signingSecretLength    = 32
+	signingSecretKeyLength = 32
+)
+
+// SigningSecrets issues the secrets api keys sign requests with. They are
+// only stored sealed with AES-GCM under auth.signing_secret_key, so reading
+// the api_keys table is not enough to sign requests. Without that key no
+// signing secrets are issued and signed requests are refused.
+type SigningSecrets struct {
+	aead cipher.AEAD
+}
+
+// NewSigningSecrets reads the base64 encoded 32 byte auth.signing_secret_key.
+func NewSigningSecrets(cfg config.AppConfig) (*SigningSecrets, error) {
+	if cfg.Auth.SigningSecretKey == "" {
+		return &SigningSecrets{}, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.Auth.SigningSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding signing secret key: %w", err)
+	}
+	if len(key) != signingSecretKeyLength {
+		return nil, fmt.Errorf("signing secret key must be %d bytes", signingSecretKeyLength)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningSecrets{aead: aead}, nil
+}
+
+func (s *SigningSecrets) IsEnabled() bool {
+	return s.aead != nil
+}
+
+// Generate returns a new secret in the base64 form handed to the client and
+// its sealed form to store.
+func (s *SigningSecrets) Generate() (string, string, error) {
+	if !s.IsEnabled() {
+		return "", "", errors.New("signing secrets are not configured")
+	}
+
+	secret := make([]byte, signingSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, secret, nil)
+
+	return base64.StdEncoding.EncodeToString(secret), base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open returns the decoded secret of a sealed one.
+func (s *SigningSecrets) Open(sealed string) ([]byte, error) {
+	if !s.IsEnabled() {
+		return nil, errors.New("signing secrets are not configured")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < s.aead.NonceSize() {
+		return nil, errors.New("sealed signing secret is too short")
+	}
+
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	return s.aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+	"worker-service/internal/dto"
+	"worker-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type lastUsage struct {
+	at time.Time
+	ip string
+}
+
+type usageKey struct {
+	apiKeyID string
+	day      time.Time
+}
+
+// UsageTracker aggregates api key usage in memory and writes it to the
+// database in the background, so tracking never slows a request down.
+type UsageTracker struct {
+	apiKeyRepo      repository.ApiKeyRepository
+	apiKeyUsageRepo repository.ApiKeyUsageRepository
+	interval        time.Duration
+
+	mu       sync.Mutex
+	lastUsed map[string]lastUsage
+	counters map[usageKey]dto.ApiKeyUsage
+}
+
+func NewUsageTracker(apiKeyRepo repository.ApiKeyRepository, apiKeyUsageRepo repository.ApiKeyUsageRepository, interval time.Duration) *UsageTracker {
+	return &UsageTracker{
+		apiKeyRepo:      apiKeyRepo,
+		apiKeyUsageRepo: apiKeyUsageRepo,
+		interval:        interval,
+		lastUsed:        make(map[string]lastUsage),
+		counters:        make(map[usageKey]dto.ApiKeyUsage),
+	}
+}
+
+func (t *UsageTracker) TrackRequest(apiKeyID string, ip string) {
+	if apiKeyID == "" {
+		return
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastUsed[apiKeyID] = lastUsage{at: now, ip: ip}
+	usage := t.counter(apiKeyID, now)
+	usage.RequestCount++
+	t.counters[usageKey{apiKeyID: apiKeyID, day: usage.Day}] = usage
+}
+
+func (t *UsageTracker) TrackSend(apiKeyID string, count int) {
+	if apiKeyID == "" || count <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.counter(apiKeyID, time.Now())
+	usage.SendCount += int64(count)
+	t.counters[usageKey{apiKeyID: apiKeyID, day: usage.Day}] = usage
+}
+
+// Run flushes the tracked usage every interval until ctx is done.
+func (t *UsageTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.Flush(context.Background())
+			return
+		case <-ticker.C:
+			t.Flush(ctx)
+		}
+	}
+}
+
+func (t *UsageTracker) Flush(ctx context.Context) {
+	t.mu.Lock()
+	lastUsed, counters := t.lastUsed, t.counters
+	t.lastUsed = make(map[string]lastUsage)
+	t.counters = make(map[usageKey]dto.ApiKeyUsage)
+	t.mu.Unlock()
+
+	for apiKeyID, used := range lastUsed {
+		if err := t.apiKeyRepo.UpdateLastUsed(ctx, apiKeyID, used.at, used.ip); err != nil {
+			logrus.Error("error updating api key last usage: ", err)
+		}
+	}
+
+	for _, usage := range counters {
+		if err := t.apiKeyUsageRepo.Increment(ctx, usage); err != nil {
+			logrus.Error("error updating api key usage: ", err)
+		}
+	}
+}
+
+func (t *UsageTracker) counter(apiKeyID string, now time.Time) dto.ApiKeyUsage {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if usage, ok := t.counters[usageKey{apiKeyID: apiKeyID, day: day}]; ok {
+		return usage
+	}
+	return dto.ApiKeyUsage{ApiKeyID: apiKeyID, Day: day}
+}
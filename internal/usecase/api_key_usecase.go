@@ -0,0 +1,356 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"math"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+	"worker-service/config"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/repository"
+	"worker-service/internal/repository/unitofwork"
+	"worker-service/internal/services"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	apiKeySecretLength  = 32
+	defaultMaxPerMinute = 60
+)
+
+type ApiKeyUsecase interface {
+	CreateApiKey(ctx context.Context, request dto.CreateApiKeyRequest) (dto.CreateApiKeyResponse, error)
+	ListApiKey(ctx context.Context, query ListApiKeyRequestQuery) (ListApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, id string) error
+	RotateApiKey(ctx context.Context, id string, request dto.RotateApiKeyRequest) (dto.CreateApiKeyResponse, error)
+	GetApiKeyUsage(ctx context.Context, id string, days int) (dto.ApiKeyUsageResponse, error)
+	ListUnusedApiKey(ctx context.Context, query ListApiKeyRequestQuery) (ListApiKeyResponse, error)
+}
+
+type apiKeyUsecase struct {
+	cfg             *config.AppConfig
+	apiKeyRepo      repository.ApiKeyRepository
+	apiKeyUsageRepo repository.ApiKeyUsageRepository
+	apiKeyCache     *services.ApiKeyCache
+	uow             unitofwork.UnitOfWork
+	signingSecrets  *services.SigningSecrets
+}
+
+type ListApiKeyRequestQuery struct {
+	Page            int
+	Limit           int
+	IncludeInactive bool
+	// UnusedDays selects keys not used for that many days, 0 uses the configured default.
+	UnusedDays int
+}
+
+type ListApiKeyResponse struct {
+	Header PaginationHeader `json:"header"`
+	List   []dto.ApiKey     `json:"list"`
+}
+
+func NewApiKeyUsecase(cfg *config.AppConfig, apiKeyRepo repository.ApiKeyRepository, apiKeyUsageRepo repository.ApiKeyUsageRepository, apiKeyCache *services.ApiKeyCache, uow unitofwork.UnitOfWork, signingSecrets *services.SigningSecrets) ApiKeyUsecase {
+	return &apiKeyUsecase{
+		cfg:             cfg,
+		apiKeyRepo:      apiKeyRepo,
+		apiKeyUsageRepo: apiKeyUsageRepo,
+		apiKeyCache:     apiKeyCache,
+		uow:             uow,
+		signingSecrets:  signingSecrets,
+	}
+}
+
+func (u *apiKeyUsecase) CreateApiKey(ctx context.Context, request dto.CreateApiKeyRequest) (dto.CreateApiKeyResponse, error) {
+	if strings.TrimSpace(request.Name) == "" || !isValidAllowedIPs(request.AllowedIPs) || !isValidScopes(request.Scopes) {
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrBadRequest
+	}
+	if request.ExpiresAt != nil && request.ExpiresAt.Before(time.Now()) {
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrBadRequest
+	}
+	if request.MaxPerMinute <= 0 {
+		request.MaxPerMinute = defaultMaxPerMinute
+	}
+
+	apiKey := dto.ApiKey{
+		ID:           ulid.Make().String(),
+		Name:         strings.TrimSpace(request.Name),
+		TenantID:     strings.TrimSpace(request.TenantID),
+		AllowedIPs:   request.AllowedIPs,
+		MaxPerMinute: request.MaxPerMinute,
+		BurstLimit:   request.BurstLimit,
+		MaxPerHour:   request.MaxPerHour,
+		MaxPerDay:    request.MaxPerDay,
+		IsActive:     true,
+		Scopes:       request.Scopes,
+		ExpiresAt:    request.ExpiresAt,
+	}
+	if apiKey.TenantID == "" {
+		apiKey.TenantID = apiKey.ID
+	}
+	response, err := u.generateApiKeySecrets(&apiKey)
+	if err != nil {
+		logrus.Error("error generating api key: ", err)
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrInternalServerError
+	}
+
+	if err := u.apiKeyRepo.Create(ctx, &apiKey); err != nil {
+		logrus.Error("error creating api key: ", err)
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrSqlError
+	}
+
+	response.ApiKey = apiKey
+	return response, nil
+}
+
+func (u *apiKeyUsecase) ListApiKey(ctx context.Context, query ListApiKeyRequestQuery) (ListApiKeyResponse, error) {
+	q := repository.Query{}
+	if !query.IncludeInactive {
+		q.Query = "is_active = ?"
+		q.Values = []interface{}{true}
+	}
+
+	return u.listApiKey(ctx, q, query)
+}
+
+// ListUnusedApiKey lists active keys without any request in the last days,
+// including keys that were never used and are older than that.
+func (u *apiKeyUsecase) ListUnusedApiKey(ctx context.Context, query ListApiKeyRequestQuery) (ListApiKeyResponse, error) {
+	days := query.UnusedDays
+	if days <= 0 {
+		days = u.cfg.Usage.UnusedAfterDays
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	q := repository.Query{
+		Query:  "is_active = ? AND COALESCE(last_used_at, created_at) < ?",
+		Values: []interface{}{true, cutoff},
+		Sort:   "COALESCE(last_used_at, created_at)",
+		Order:  "ASC",
+	}
+
+	return u.listApiKey(ctx, q, query)
+}
+
+func (u *apiKeyUsecase) GetApiKeyUsage(ctx context.Context, id string, days int) (dto.ApiKeyUsageResponse, error) {
+	apiKey, err := u.apiKeyRepo.FetchOne(ctx, repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{id},
+	})
+	if err != nil {
+		logrus.Error("error fetching api key: ", err)
+		return dto.ApiKeyUsageResponse{}, error_wrap.ErrNotFound
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -days+1)
+	usages, err := u.apiKeyUsageRepo.Fetch(ctx, repository.Query{
+		Query:  "api_key_id = ? AND day >= ?",
+		Values: []interface{}{id, since},
+		Sort:   "day",
+	})
+	if err != nil {
+		logrus.Error("error fetching api key usage: ", err)
+		return dto.ApiKeyUsageResponse{}, error_wrap.ErrSqlError
+	}
+
+	response := dto.ApiKeyUsageResponse{
+		ApiKey: apiKey,
+		Daily:  usages,
+	}
+	for _, usage := range usages {
+		response.TotalRequests += usage.RequestCount
+		response.TotalSends += usage.SendCount
+	}
+
+	return response, nil
+}
+
+func (u *apiKeyUsecase) listApiKey(ctx context.Context, q repository.Query, query ListApiKeyRequestQuery) (ListApiKeyResponse, error) {
+	totalData, err := u.apiKeyRepo.Count(ctx, q)
+	if err != nil {
+		logrus.Error("error counting api keys: ", err)
+		return ListApiKeyResponse{}, error_wrap.ErrSqlError
+	}
+
+	q.Page = query.Page
+	q.Limit = query.Limit
+	data, err := u.apiKeyRepo.Fetch(ctx, q)
+	if err != nil {
+		logrus.Error("error fetching api keys: ", err)
+		return ListApiKeyResponse{}, error_wrap.ErrSqlError
+	}
+
+	return ListApiKeyResponse{
+		Header: PaginationHeader{
+			CurrentPage: int64(query.Page),
+			PerPage:     int64(query.Limit),
+			TotalData:   totalData,
+			TotalPages:  int64(math.Ceil(float64(totalData) / float64(query.Limit))),
+		},
+		List: data,
+	}, nil
+}
+
+func (u *apiKeyUsecase) RevokeApiKey(ctx context.Context, id string) error {
+	apiKey, err := u.fetchActiveApiKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	apiKey.IsActive = false
+	apiKey.RevokedAt = &now
+	if err := u.apiKeyRepo.Update(ctx, id, &apiKey, "is_active", "revoked_at"); err != nil {
+		logrus.Error("error revoking api key: ", err)
+		return error_wrap.ErrSqlError
+	}
+
+	if err := u.apiKeyCache.Invalidate(ctx, apiKey); err != nil {
+		logrus.Error("error invalidating api key cache: ", err)
+		return error_wrap.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// RotateApiKey issues a new secret with the same settings. The old key stays
+// valid for the requested overlap so callers can roll out the new secret.
+func (u *apiKeyUsecase) RotateApiKey(ctx context.Context, id string, request dto.RotateApiKeyRequest) (dto.CreateApiKeyResponse, error) {
+	var overlap time.Duration
+	if request.Overlap != "" {
+		var err error
+		overlap, err = time.ParseDuration(request.Overlap)
+		if err != nil || overlap < 0 {
+			return dto.CreateApiKeyResponse{}, error_wrap.ErrBadRequest
+		}
+	}
+
+	oldKey, err := u.fetchActiveApiKey(ctx, id)
+	if err != nil {
+		return dto.CreateApiKeyResponse{}, err
+	}
+
+	newKey := oldKey
+	newKey.ID = ""
+	newKey.CreatedAt = time.Time{}
+	newKey.UpdatedAt = nil
+	newKey.RevokedAt = nil
+	newKey.RotatedFrom = oldKey.ID
+	// The new key starts unused
+	newKey.LastUsedAt = nil
+	newKey.LastUsedIP = ""
+	response, err := u.generateApiKeySecrets(&newKey)
+	if err != nil {
+		logrus.Error("error generating api key: ", err)
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrInternalServerError
+	}
+
+	now := time.Now()
+	if overlap > 0 {
+		expiresAt := now.Add(overlap)
+		if oldKey.ExpiresAt == nil || expiresAt.Before(*oldKey.ExpiresAt) {
+			oldKey.ExpiresAt = &expiresAt
+		}
+	} else {
+		oldKey.IsActive = false
+		oldKey.RevokedAt = &now
+	}
+
+	err = u.uow.Do(ctx, func(uows unitofwork.UnitOfWorkStore) error {
+		if err := uows.ApiKeys().Create(ctx, &newKey); err != nil {
+			logrus.Error("error creating rotated api key: ", err)
+			return error_wrap.ErrSqlError
+		}
+
+		if err := uows.ApiKeys().Update(ctx, oldKey.ID, &oldKey, "is_active", "revoked_at", "expires_at"); err != nil {
+			logrus.Error("error updating rotated api key: ", err)
+			return error_wrap.ErrSqlError
+		}
+
+		return nil
+	}, sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		logrus.Error("error uow: ", err)
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrInternalServerError
+	}
+
+	if err := u.apiKeyCache.Invalidate(ctx, oldKey); err != nil {
+		logrus.Error("error invalidating api key cache: ", err)
+		return dto.CreateApiKeyResponse{}, error_wrap.ErrInternalServerError
+	}
+
+	response.ApiKey = newKey
+	return response, nil
+}
+
+func (u *apiKeyUsecase) fetchActiveApiKey(ctx context.Context, id string) (dto.ApiKey, error) {
+	apiKey, err := u.apiKeyRepo.FetchOne(ctx, repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{id},
+	})
+	if err != nil {
+		logrus.Error("error fetching api key: ", err)
+		return dto.ApiKey{}, error_wrap.ErrNotFound
+	}
+	if !apiKey.IsUsable(time.Now()) {
+		return dto.ApiKey{}, error_wrap.ErrNotFound
+	}
+
+	return apiKey, nil
+}
+
+// generateApiKeySecrets fills the hash and sealed signing secret of apiKey and
+// returns the plaintext secrets, the key in the base64 form expected by the
+// X-API-KEY header. The signing secret is left out when signing is off.
+func (u *apiKeyUsecase) generateApiKeySecrets(apiKey *dto.ApiKey) (dto.CreateApiKeyResponse, error) {
+	secret := make([]byte, apiKeySecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return dto.CreateApiKeyResponse{}, err
+	}
+	apiKey.KeyHash = HashAPIKey(secret)
+	response := dto.CreateApiKeyResponse{Key: base64.StdEncoding.EncodeToString(secret)}
+
+	apiKey.SigningKey = ""
+	if u.signingSecrets.IsEnabled() {
+		signingSecret, sealed, err := u.signingSecrets.Generate()
+		if err != nil {
+			return dto.CreateApiKeyResponse{}, err
+		}
+		apiKey.SigningKey = sealed
+		response.SigningSecret = signingSecret
+	}
+
+	return response, nil
+}
+
+func isValidAllowedIPs(allowedIPs []string) bool {
+	for _, allowed := range allowedIPs {
+		allowed = strings.TrimSpace(allowed)
+		if _, err := netip.ParsePrefix(allowed); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(allowed); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(dto.AvailableScopes, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,48 +2,295 @@ package usecase
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+	"worker-service/config"
 	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/pkg/redis"
 	"worker-service/internal/repository"
+	"worker-service/internal/services"
+
+	"github.com/sirupsen/logrus"
 )
 
+// RejectedIPCounterTTL is how long the count of requests a key got from non
+// allowed ips lives after the last of them, so it counts rejections in a
+// rolling window instead of forever.
+const RejectedIPCounterTTL = 24 * time.Hour
+
 type authUsecase struct {
-	apiKeyRepo repository.ApiKeyRepository
+	cfg             *config.AppConfig
+	apiKeyRepo      repository.ApiKeyRepository
+	apiKeyCache     *services.ApiKeyCache
+	rejectedCounter *redis.RedisClient[int64]
+	nonces          *redis.RedisClient[bool]
+	lockout         *services.AuthLockout
+	signingSecrets  *services.SigningSecrets
 }
 
 type AuthUsecase interface {
+	Authenticate(ctx context.Context, ip string, credentials dto.Credentials) (dto.VerifyAPIKeyResponse, error)
 	VerifyAPIKey(ctx context.Context, key string) (dto.VerifyAPIKeyResponse, error)
+	VerifySignedRequest(ctx context.Context, request dto.SignedRequest) (dto.VerifyAPIKeyResponse, error)
+	AuthorizeIP(ctx context.Context, apiKey dto.VerifyAPIKeyResponse, ip string) error
+	CheckLockout(ctx context.Context, ip string) (time.Duration, error)
+	RecordFailedAuth(ctx context.Context, ip string)
 }
 
-func NewAuthUsecase(apiKeyRepo repository.ApiKeyRepository) AuthUsecase {
+func NewAuthUsecase(cfg *config.AppConfig, apiKeyRepo repository.ApiKeyRepository, apiKeyCache *services.ApiKeyCache, rejectedCounter *redis.RedisClient[int64], nonces *redis.RedisClient[bool], lockout *services.AuthLockout, signingSecrets *services.SigningSecrets) AuthUsecase {
 	return &authUsecase{
-		apiKeyRepo: apiKeyRepo,
+		cfg:             cfg,
+		apiKeyRepo:      apiKeyRepo,
+		apiKeyCache:     apiKeyCache,
+		rejectedCounter: rejectedCounter,
+		nonces:          nonces,
+		lockout:         lockout,
+		signingSecrets:  signingSecrets,
 	}
 }
 
+// Authenticate verifies the credentials of a request from ip and checks ip
+// against the allow list of the key. Bad credentials count towards a lockout
+// of ip, see CheckLockout. Successes do not clear them, they only expire with
+// the lockout window.
+func (u *authUsecase) Authenticate(ctx context.Context, ip string, credentials dto.Credentials) (dto.VerifyAPIKeyResponse, error) {
+	var (
+		res dto.VerifyAPIKeyResponse
+		err error
+	)
+	switch {
+	case credentials.APIKey != "":
+		res, err = u.VerifyAPIKey(ctx, credentials.APIKey)
+		if err != nil || !res.IsValid {
+			err = error_wrap.ErrApiKeyIsInvalid
+		}
+	case credentials.Signed != nil:
+		res, err = u.VerifySignedRequest(ctx, *credentials.Signed)
+	default:
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrApiKeyIsMissing
+	}
+	if err != nil {
+		if errors.Is(err, error_wrap.ErrApiKeyIsInvalid) || errors.Is(err, error_wrap.ErrSignatureIsInvalid) {
+			u.RecordFailedAuth(ctx, ip)
+		}
+		return dto.VerifyAPIKeyResponse{}, err
+	}
+
+	if err := u.AuthorizeIP(ctx, res, ip); err != nil {
+		return dto.VerifyAPIKeyResponse{}, err
+	}
+
+	return res, nil
+}
+
 func (u *authUsecase) VerifyAPIKey(ctx context.Context, key string) (dto.VerifyAPIKeyResponse, error) {
 	decodedKey, err := base64.StdEncoding.DecodeString(key)
 	if err != nil {
 		return dto.VerifyAPIKeyResponse{}, err
 	}
 
-	hash := sha256.Sum256([]byte(decodedKey))
-	keyHash := hex.EncodeToString(hash[:])
-
-	apiKey, err := u.apiKeyRepo.FetchOne(ctx, repository.Query{
+	keyHash := HashAPIKey(decodedKey)
+	return u.fetchVerifiedAPIKey(ctx, keyHash, repository.Query{
 		Query:  "key_hash = ?",
 		Values: []interface{}{keyHash},
 	})
-	if err != nil || !apiKey.IsActive {
+}
+
+// VerifySignedRequest authenticates a request signed with the signing secret
+// of the key instead of carrying the api key secret. The timestamp must be within the configured skew and
+// every nonce is accepted once per key.
+func (u *authUsecase) VerifySignedRequest(ctx context.Context, request dto.SignedRequest) (dto.VerifyAPIKeyResponse, error) {
+	if request.KeyID == "" || request.Nonce == "" || request.Signature == "" {
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+
+	timestamp, err := strconv.ParseInt(request.Timestamp, 10, 64)
+	if err != nil {
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > u.cfg.Auth.SignatureMaxSkew {
+		logrus.WithField("api_key_id", request.KeyID).Warn("signed request outside of the allowed clock skew")
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+
+	res, err := u.fetchVerifiedAPIKey(ctx, services.ApiKeyIDCacheKey(request.KeyID), repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{request.KeyID},
+	})
+	if err != nil || !res.IsValid || res.SigningKey == "" || !u.signingSecrets.IsEnabled() {
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+
+	secret, err := u.signingSecrets.Open(res.SigningKey)
+	if err != nil {
+		logrus.WithField("api_key_id", request.KeyID).Error("error opening signing secret: ", err)
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+
+	signature, err := hex.DecodeString(request.Signature)
+	if err != nil || !hmac.Equal(signature, signRequest(secret, request)) {
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+
+	// Only remember nonces of valid signatures so nobody can burn them for the caller
+	isNew, err := u.nonces.SetNX(ctx, request.KeyID+":"+request.Nonce, true)
+	if err != nil {
+		logrus.Error("error storing request nonce: ", err)
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrInternalServerError
+	}
+	if !isNew {
+		logrus.WithField("api_key_id", request.KeyID).Warn("replayed signed request")
+		return dto.VerifyAPIKeyResponse{}, error_wrap.ErrSignatureIsInvalid
+	}
+
+	return res, nil
+}
+
+func (u *authUsecase) fetchVerifiedAPIKey(ctx context.Context, cacheKey string, query repository.Query) (dto.VerifyAPIKeyResponse, error) {
+	if res, ok := u.apiKeyCache.Get(ctx, cacheKey); ok {
+		if res.ExpiresAt != nil && !time.Now().Before(*res.ExpiresAt) {
+			return dto.VerifyAPIKeyResponse{}, nil
+		}
+		return res, nil
+	}
+
+	apiKey, err := u.apiKeyRepo.FetchOne(ctx, query)
+	if err != nil || !apiKey.IsUsable(time.Now()) {
 		return dto.VerifyAPIKeyResponse{}, err
 	}
 
-	return dto.VerifyAPIKeyResponse{
-		IsValid:            true,
-		ServiceName:        apiKey.Name,
-		ThresholdRateLimit: apiKey.MaxPerMinute,
-		APIKey:             apiKey.KeyHash,
-	}, nil
+	res := dto.VerifyAPIKeyResponse{
+		IsValid:     true,
+		ID:          apiKey.ID,
+		ServiceName: apiKey.Name,
+		TenantID:    apiKey.TenantID,
+		Quota: dto.RateLimitQuota{
+			PerMinute: apiKey.MaxPerMinute,
+			Burst:     apiKey.BurstLimit,
+			PerHour:   apiKey.MaxPerHour,
+			PerDay:    apiKey.MaxPerDay,
+		},
+		APIKey:     apiKey.KeyHash,
+		AllowedIPs: apiKey.AllowedIPs,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		SigningKey: apiKey.SigningKey,
+	}
+	u.apiKeyCache.Set(ctx, cacheKey, res)
+
+	return res, nil
+}
+
+func signRequest(secret []byte, request dto.SignedRequest) []byte {
+	bodyHash := sha256.Sum256(request.Body)
+	payload := strings.Join([]string{
+		request.Method,
+		request.Path,
+		request.Timestamp,
+		request.Nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// AuthorizeIP checks ip against the allow list of the key. An empty allow list
+// accepts every address; entries may be single addresses or CIDR ranges.
+func (u *authUsecase) AuthorizeIP(ctx context.Context, apiKey dto.VerifyAPIKeyResponse, ip string) error {
+	if len(apiKey.AllowedIPs) == 0 {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err == nil && isIPAllowed(apiKey.AllowedIPs, addr.Unmap()) {
+		return nil
+	}
+
+	count, err := u.rejectedCounter.Incr(ctx, apiKey.ID)
+	if err != nil {
+		logrus.Error("error counting rejected ip: ", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"api_key_id":     apiKey.ID,
+		"service":        apiKey.ServiceName,
+		"ip":             ip,
+		"rejected_count": count,
+	}).Warn("api key used from a non allowed ip")
+
+	return error_wrap.ErrIPorServiceBlocked
+}
+
+func isIPAllowed(allowedIPs []string, addr netip.Addr) bool {
+	for _, allowed := range allowedIPs {
+		allowed = strings.TrimSpace(allowed)
+		if strings.Contains(allowed, "/") {
+			prefix, err := netip.ParsePrefix(allowed)
+			if err != nil {
+				logrus.Error("error parsing allowed cidr: ", err)
+				continue
+			}
+			if prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+
+		allowedAddr, err := netip.ParseAddr(allowed)
+		if err != nil {
+			logrus.Error("error parsing allowed ip: ", err)
+			continue
+		}
+		if allowedAddr.Unmap() == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckLockout returns how long ip is still locked out after repeated failed
+// authentications. Lockouts are not enforced when redis is unavailable.
+func (u *authUsecase) CheckLockout(ctx context.Context, ip string) (time.Duration, error) {
+	lockedFor, err := u.lockout.LockedFor(ctx, ip)
+	if err != nil {
+		logrus.Error("error checking auth lockout: ", err)
+		return 0, nil
+	}
+	if lockedFor > 0 {
+		return lockedFor, error_wrap.ErrTooManyRequests
+	}
+	return 0, nil
+}
+
+func (u *authUsecase) RecordFailedAuth(ctx context.Context, ip string) {
+	lockout, err := u.lockout.RecordFailure(ctx, ip)
+	if err != nil {
+		logrus.Error("error recording failed auth: ", err)
+		return
+	}
+	if !lockout.Triggered() {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"event":    "auth_lockout",
+		"ip":       ip,
+		"failures": lockout.Failures,
+		"level":    lockout.Level,
+		"duration": lockout.Duration.String(),
+	}).Warn("ip locked out after repeated failed authentications")
 }
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/pkg/redis"
+	"worker-service/internal/repository"
+	"worker-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+type BatchUsecase interface {
+	GetBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error)
+	ListBatchEmails(ctx context.Context, query ListEmailRequestQuery) (ListEmailResponse, error)
+	PauseBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error)
+	ResumeBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error)
+	CancelBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error)
+}
+
+type batchUsecase struct {
+	batchRepo        repository.BatchRepository
+	emailHistoryRepo repository.EmailHistoryRepository
+	redisClient      *redis.RedisClient[dto.QueuedEmail]
+	emailUsecase     EmailUsecase
+	statusChanges    emailStatusChanges
+}
+
+func NewBatchUsecase(batchRepo repository.BatchRepository, emailHistoryRepo repository.EmailHistoryRepository, redisClient *redis.RedisClient[dto.QueuedEmail], emailUsecase EmailUsecase, emailEvents *services.EmailEvents) BatchUsecase {
+	return &batchUsecase{
+		batchRepo:        batchRepo,
+		emailHistoryRepo: emailHistoryRepo,
+		redisClient:      redisClient,
+		emailUsecase:     emailUsecase,
+		statusChanges:    emailStatusChanges{emailEvents: emailEvents, batchRepo: batchRepo},
+	}
+}
+
+func (u *batchUsecase) GetBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error) {
+	batch, err := u.fetchBatch(ctx, scope, id)
+	if err != nil {
+		return dto.Batch{}, err
+	}
+
+	return reportBatch(batch), nil
+}
+
+// ListBatchEmails lists the emails of query.BatchID with the email listing
+// filters and pagination.
+func (u *batchUsecase) ListBatchEmails(ctx context.Context, query ListEmailRequestQuery) (ListEmailResponse, error) {
+	if _, err := u.fetchBatch(ctx, query.TenantScope, query.BatchID); err != nil {
+		return ListEmailResponse{}, err
+	}
+
+	return u.emailUsecase.ListEmail(ctx, query)
+}
+
+// PauseBatch holds back the queued emails of an active or receiving batch,
+// workers leave them queued until the batch is resumed.
+func (u *batchUsecase) PauseBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error) {
+	batch, err := u.moveBatch(ctx, scope, id, []string{dto.BatchActive, dto.BatchReceiving}, dto.BatchPaused)
+	if err != nil {
+		return dto.Batch{}, err
+	}
+
+	return reportBatch(batch), nil
+}
+
+// ResumeBatch lets workers send the queued emails of a paused batch again.
+// The emails workers skipped while it was paused are queued again in the
+// background.
+func (u *batchUsecase) ResumeBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error) {
+	batch, err := u.moveBatch(ctx, scope, id, []string{dto.BatchPaused}, dto.BatchActive)
+	if err != nil {
+		return dto.Batch{}, err
+	}
+
+	// Queueing outlives the request
+	go u.walkQueuedEmails(context.Background(), batch.ID, u.requeueEmails)
+
+	return reportBatch(batch), nil
+}
+
+// CancelBatch stops a batch for good, its queued emails are cancelled in the
+// background and uploads into it stop.
+func (u *batchUsecase) CancelBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error) {
+	batch, err := u.moveBatch(ctx, scope, id, []string{dto.BatchActive, dto.BatchPaused, dto.BatchReceiving}, dto.BatchCancelled)
+	if err != nil {
+		return dto.Batch{}, err
+	}
+
+	// Cancelling outlives the request
+	go u.walkQueuedEmails(context.Background(), batch.ID, u.cancelEmails)
+
+	return reportBatch(batch), nil
+}
+
+func (u *batchUsecase) fetchBatch(ctx context.Context, scope dto.TenantScope, id string) (dto.Batch, error) {
+	q := repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{id},
+	}
+	if !scope.AllTenants {
+		q.AddTermCondition(" tenant_id = ?", scope.TenantID)
+	}
+
+	batch, err := u.batchRepo.FetchOne(ctx, q)
+	if err != nil {
+		logrus.Error("error fetching email batch: ", err)
+		return dto.Batch{}, error_wrap.ErrNotFound
+	}
+
+	return batch, nil
+}
+
+// moveBatch moves the batch from one of the from statuses to status, batches
+// in any other status can not make that move.
+func (u *batchUsecase) moveBatch(ctx context.Context, scope dto.TenantScope, id string, from []string, status string) (dto.Batch, error) {
+	batch, err := u.fetchBatch(ctx, scope, id)
+	if err != nil {
+		return dto.Batch{}, err
+	}
+	if !slices.Contains(from, batch.Status) {
+		return dto.Batch{}, error_wrap.ErrBadRequest
+	}
+
+	moved, err := u.batchRepo.UpdateStatus(ctx, batch.ID, from, status)
+	if err != nil {
+		logrus.Error("error updating email batch status: ", err)
+		return dto.Batch{}, error_wrap.ErrSqlError
+	}
+	if !moved {
+		// Another request moved it first
+		return dto.Batch{}, error_wrap.ErrBadRequest
+	}
+	batch.Status = status
+
+	return batch, nil
+}
+
+// walkQueuedEmails hands the queued emails of the batch to process in ID
+// order, one page at a time.
+func (u *batchUsecase) walkQueuedEmails(ctx context.Context, batchID string, process func(ctx context.Context, emails []dto.EmailHistory) error) {
+	log := logrus.WithField("batch_id", batchID)
+
+	lastID := ""
+	for {
+		q := repository.Query{
+			Query:  "batch_id = ? AND status = ?",
+			Values: []interface{}{batchID, uint(dto.EmailHistoryQueued)},
+			Sort:   "id",
+			Order:  "ASC",
+			Limit:  emailJobBatchSize,
+		}
+		if lastID != "" {
+			q.AddTermCondition(" id > ?", lastID)
+		}
+
+		emails, err := u.emailHistoryRepo.Fetch(ctx, q)
+		if err != nil {
+			log.Error("error fetching queued batch emails: ", err)
+			return
+		}
+		if len(emails) == 0 {
+			return
+		}
+
+		if err := process(ctx, emails); err != nil {
+			log.Error("error processing queued batch emails: ", err)
+			return
+		}
+
+		lastID = emails[len(emails)-1].ID
+	}
+}
+
+func (u *batchUsecase) requeueEmails(ctx context.Context, emails []dto.EmailHistory) error {
+	for _, email := range emails {
+		err := u.redisClient.Enqueue(ctx, dto.QueuedEmail{
+			EmailTask: dto.EmailTask{
+				From:    email.From,
+				To:      email.To,
+				Subject: email.Subject,
+				Body:    email.Body,
+			},
+			EmailID:   email.ID,
+			MessageID: email.MessageID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *batchUsecase) cancelEmails(ctx context.Context, emails []dto.EmailHistory) error {
+	ids := make([]string, 0, len(emails))
+	for _, email := range emails {
+		ids = append(ids, email.ID)
+	}
+
+	// Emails a worker sent meanwhile keep their status
+	cancelled, err := u.emailHistoryRepo.UpdateStatus(ctx, ids, []dto.EmailHistoryStatus{dto.EmailHistoryQueued}, dto.EmailHistoryCancelled)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range emails {
+		if slices.Contains(cancelled, email.ID) {
+			u.statusChanges.record(ctx, email, dto.EmailHistoryQueued, dto.EmailHistoryCancelled, "")
+		}
+	}
+	return nil
+}
+
+// reportBatch reports active batches with nothing left queued as completed.
+func reportBatch(batch dto.Batch) dto.Batch {
+	if batch.Status == dto.BatchActive && batch.Queued == 0 {
+		batch.Status = dto.BatchCompleted
+	}
+	return batch
+}
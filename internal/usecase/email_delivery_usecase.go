@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/pkg/redis"
+	"worker-service/internal/repository"
+	"worker-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EmailClaimTTL is how long a worker holds its claim on a queued email, longer
+// than any send takes.
+const EmailClaimTTL = 10 * time.Minute
+
+type EmailDeliveryUsecase interface {
+	// DeliverEmail sends a queued email and records the outcome on its history.
+	DeliverEmail(ctx context.Context, email dto.QueuedEmail) (SendSingleEmailResponse, error)
+}
+
+type emailDeliveryUsecase struct {
+	emailHistoryRepo repository.EmailHistoryRepository
+	emailService     services.EmailService
+	messageStore     repository.EmailMessageStore
+	usageTracker     *services.UsageTracker
+	batchRepo        repository.BatchRepository
+	claims           *redis.RedisClient[bool]
+	statusChanges    emailStatusChanges
+}
+
+type SendSingleEmailResponse struct {
+	ID        string `json:"id"`
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+	// SmtpResponse is the reply of the SMTP server, also set when it rejected the email.
+	SmtpResponse string         `json:"smtp_response,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Quota        dto.QuotaUsage `json:"quota"`
+}
+
+// IsQueued reports whether the email was not attempted yet.
+func (r SendSingleEmailResponse) IsQueued() bool {
+	return r.Status == dto.EmailHistoryStatusToString[dto.EmailHistoryQueued]
+}
+
+func NewEmailDeliveryUsecase(emailHistoryRepo repository.EmailHistoryRepository, emailService services.EmailService, messageStore repository.EmailMessageStore, usageTracker *services.UsageTracker, emailEvents *services.EmailEvents, batchRepo repository.BatchRepository, claims *redis.RedisClient[bool]) EmailDeliveryUsecase {
+	return &emailDeliveryUsecase{
+		emailHistoryRepo: emailHistoryRepo,
+		emailService:     emailService,
+		messageStore:     messageStore,
+		usageTracker:     usageTracker,
+		batchRepo:        batchRepo,
+		claims:           claims,
+		statusChanges:    emailStatusChanges{emailEvents: emailEvents, batchRepo: batchRepo},
+	}
+}
+
+func (u *emailDeliveryUsecase) DeliverEmail(ctx context.Context, email dto.QueuedEmail) (SendSingleEmailResponse, error) {
+	log := logrus.WithField("email_id", email.EmailID)
+	response := SendSingleEmailResponse{
+		ID:        email.EmailID,
+		MessageID: email.MessageID,
+	}
+
+	// Until the queue has drained of the bare email tasks it held before
+	// emails were recorded when queued, they decode without an id and are sent
+	// as they used to be, untracked
+	if email.EmailID == "" {
+		log.Warn("sending an email queued in the old format")
+		rendered, err := u.emailService.SendEmail(ctx, email.EmailTask)
+		response.MessageID = rendered.MessageID
+		response.SmtpResponse = rendered.Response
+		if err != nil {
+			log.Error("error sending email: ", err)
+			response.Error = err.Error()
+			response.Status = dto.EmailHistoryStatusToString[dto.EmailHistoryFailed]
+			return response, nil
+		}
+		response.Status = dto.EmailHistoryStatusToString[dto.EmailHistorySuccess]
+		return response, nil
+	}
+
+	history, err := u.emailHistoryRepo.FetchOne(ctx, repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{email.EmailID},
+	})
+	if err != nil {
+		log.Error("error fetching queued email: ", err)
+		return response, error_wrap.ErrSqlError
+	}
+
+	// Emails cancelled while queued are dropped
+	response.Status = dto.EmailHistoryStatusToString[dto.EmailHistoryStatus(history.Status)]
+	if history.Status != uint(dto.EmailHistoryQueued) {
+		return response, nil
+	}
+
+	// Emails of paused batches stay queued, they are queued again on resume
+	if history.BatchID != "" {
+		batch, err := u.batchRepo.FetchOne(ctx, repository.Query{
+			Query:  "id = ?",
+			Values: []interface{}{history.BatchID},
+		})
+		if err != nil {
+			log.Error("error fetching email batch: ", err)
+			return response, error_wrap.ErrSqlError
+		}
+		if batch.Status == dto.BatchPaused || batch.Status == dto.BatchCancelled {
+			return response, nil
+		}
+	}
+
+	// An email queued again may still be on the queue, only one copy is sent
+	claimed, err := u.claims.SetNX(ctx, email.EmailID, true)
+	if err != nil {
+		log.Error("error claiming queued email: ", err)
+		return response, error_wrap.ErrInternalServerError
+	}
+	if !claimed {
+		return response, nil
+	}
+
+	status := dto.EmailHistorySuccess
+	rendered, sendErr := u.emailService.SendQueuedEmail(ctx, email)
+	response.SmtpResponse = rendered.Response
+	if sendErr != nil {
+		// Failed sends stay pending so they can be retried like bulk sends
+		log.Error("error sending email: ", sendErr)
+		response.Error = sendErr.Error()
+		status = dto.EmailHistoryPending
+	} else {
+		storeEmailMessage(ctx, u.messageStore, email.EmailID, rendered)
+		u.usageTracker.TrackSend(history.ApiKeyID, len(email.Recipients()))
+	}
+
+	moved, err := u.emailHistoryRepo.UpdateStatus(ctx, []string{email.EmailID}, []dto.EmailHistoryStatus{dto.EmailHistoryQueued}, status)
+	if err != nil {
+		log.Error("error updating email status: ", err)
+		return response, error_wrap.ErrSqlError
+	}
+	response.Status = dto.EmailHistoryStatusToString[status]
+	if len(moved) > 0 {
+		u.statusChanges.recordSend(ctx, history, status, response)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/repository"
+	"worker-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EmailEventUsecase interface {
+	// SubscribeEvents streams the status changes of the emails the caller may
+	// see until ctx is done.
+	SubscribeEvents(ctx context.Context, request SubscribeEmailEventsRequest) (<-chan dto.EmailStatusEvent, error)
+}
+
+type emailEventUsecase struct {
+	emailEvents *services.EmailEvents
+}
+
+// SubscribeEmailEventsRequest narrows the stream to one email, by ID or
+// Message-ID, or to one bulk send. LastEventID resumes after that event.
+type SubscribeEmailEventsRequest struct {
+	dto.TenantScope
+	LastEventID string
+	EmailID     string
+	MessageID   string
+	BatchID     string
+}
+
+func NewEmailEventUsecase(emailEvents *services.EmailEvents) EmailEventUsecase {
+	return &emailEventUsecase{
+		emailEvents: emailEvents,
+	}
+}
+
+func (u *emailEventUsecase) SubscribeEvents(ctx context.Context, request SubscribeEmailEventsRequest) (<-chan dto.EmailStatusEvent, error) {
+	if !request.AllTenants && request.TenantID == "" {
+		return nil, error_wrap.ErrForbidden
+	}
+	if request.LastEventID != "" && !services.IsValidEventID(request.LastEventID) {
+		return nil, error_wrap.ValidationErrors{{
+			Field:   "last_event_id",
+			Code:    error_wrap.CodeInvalidType,
+			Message: "must be an event id",
+		}}
+	}
+
+	events, err := u.emailEvents.Subscribe(ctx, request.LastEventID, func(event dto.EmailStatusEvent) bool {
+		return (request.AllTenants || event.TenantID == request.TenantID) &&
+			(request.EmailID == "" || event.EmailID == request.EmailID) &&
+			(request.MessageID == "" || event.MessageID == request.MessageID) &&
+			(request.BatchID == "" || event.BatchID == request.BatchID)
+	})
+	if err != nil {
+		logrus.Error("error subscribing to email events: ", err)
+		return nil, error_wrap.ErrInternalServerError
+	}
+
+	return events, nil
+}
+
+// emailStatusChanges announces status changes of emails and keeps the counts
+// of the batches they belong to.
+type emailStatusChanges struct {
+	emailEvents *services.EmailEvents
+	batchRepo   repository.BatchRepository
+}
+
+// record announces that email moved from one status to another, errMsg tells
+// why a send failed.
+func (c emailStatusChanges) record(ctx context.Context, email dto.EmailHistory, from dto.EmailHistoryStatus, to dto.EmailHistoryStatus, errMsg string) {
+	c.count(ctx, email, from, to)
+	c.publish(ctx, email, to, errMsg)
+}
+
+// recordSend is record for a send of a queued email, announcing the reply of
+// the SMTP server along with the status.
+func (c emailStatusChanges) recordSend(ctx context.Context, email dto.EmailHistory, to dto.EmailHistoryStatus, response SendSingleEmailResponse) {
+	c.count(ctx, email, dto.EmailHistoryQueued, to)
+	event := newEmailStatusEvent(email, to, response.Error)
+	event.SmtpResponse = response.SmtpResponse
+	c.emailEvents.Publish(ctx, event)
+}
+
+// count moves email from one status count of its batch to another.
+func (c emailStatusChanges) count(ctx context.Context, email dto.EmailHistory, from dto.EmailHistoryStatus, to dto.EmailHistoryStatus) {
+	if email.BatchID != "" {
+		err := c.batchRepo.AddCounts(ctx, email.BatchID, 0, map[dto.EmailHistoryStatus]int64{from: -1, to: 1})
+		if err != nil {
+			logrus.WithField("batch_id", email.BatchID).Error("error counting batch emails: ", err)
+		}
+	}
+}
+
+// publish announces email in status without touching batch counts, as for
+// emails just recorded.
+func (c emailStatusChanges) publish(ctx context.Context, email dto.EmailHistory, status dto.EmailHistoryStatus, errMsg string) {
+	c.emailEvents.Publish(ctx, newEmailStatusEvent(email, status, errMsg))
+}
+
+func newEmailStatusEvent(email dto.EmailHistory, status dto.EmailHistoryStatus, errMsg string) dto.EmailStatusEvent {
+	return dto.EmailStatusEvent{
+		EmailID:   email.ID,
+		MessageID: email.MessageID,
+		BatchID:   email.BatchID,
+		TenantID:  email.TenantID,
+		Status:    dto.EmailHistoryStatusToString[status],
+		Error:     errMsg,
+	}
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+var ExportContentTypes = map[string]string{
+	ExportFormatCSV:    "text/csv; charset=utf-8",
+	ExportFormatNDJSON: "application/x-ndjson",
+}
+
+var emailExportColumns = []string{"id", "created_at", "updated_at", "tenant_id", "api_key_id", "message_id", "from", "to", "subject", "status", "body"}
+
+type EmailExportUsecase interface {
+	ExportEmail(ctx context.Context, query ListEmailRequestQuery, format string, w io.Writer) error
+}
+
+type emailExportUsecase struct {
+	emailHistoryRepo repository.EmailHistoryRepository
+}
+
+func NewEmailExportUsecase(emailHistoryRepo repository.EmailHistoryRepository) EmailExportUsecase {
+	return &emailExportUsecase{
+		emailHistoryRepo: emailHistoryRepo,
+	}
+}
+
+// ExportEmail writes every history matching the ListEmail filters to w, in
+// the order ListEmail would page through them. Nothing is written when the
+// query or format is invalid.
+func (u *emailExportUsecase) ExportEmail(ctx context.Context, query ListEmailRequestQuery, format string, w io.Writer) error {
+	if _, isExist := ExportContentTypes[format]; !isExist {
+		return error_wrap.ErrBadRequest
+	}
+
+	q, err := buildEmailQueryDetail(query)
+	if err != nil {
+		return err
+	}
+	if query.IsAscending {
+		q.Order = "ASC"
+	}
+
+	var (
+		buffer = bufio.NewWriter(w)
+		write  func(email dto.EmailHistory) error
+		flush  = buffer.Flush
+	)
+	switch format {
+	case ExportFormatCSV:
+		csvWriter := csv.NewWriter(buffer)
+		if err := csvWriter.Write(emailExportColumns); err != nil {
+			return err
+		}
+		write = func(email dto.EmailHistory) error {
+			return csvWriter.Write(emailExportRecord(email))
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+			return buffer.Flush()
+		}
+	case ExportFormatNDJSON:
+		encoder := json.NewEncoder(buffer)
+		write = func(email dto.EmailHistory) error {
+			return encoder.Encode(email)
+		}
+	}
+
+	if err := u.emailHistoryRepo.Stream(ctx, q, write); err != nil {
+		logrus.Error("error exporting email: ", err)
+		return error_wrap.ErrSqlError
+	}
+
+	return flush()
+}
+
+func emailExportRecord(email dto.EmailHistory) []string {
+	updatedAt := ""
+	if email.UpdatedAt != nil {
+		updatedAt = email.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	return []string{
+		email.ID,
+		email.CreatedAt.Format(time.RFC3339Nano),
+		updatedAt,
+		email.TenantID,
+		email.ApiKeyID,
+		email.MessageID,
+		csvText(email.From),
+		csvText(email.To),
+		csvText(email.Subject),
+		dto.EmailHistoryStatusToString[dto.EmailHistoryStatus(email.Status)],
+		csvText(email.Body),
+	}
+}
+
+// csvText keeps spreadsheets from running user provided text as a formula by
+// quoting cells that start like one.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ingestCheckEvery is how many emails an upload queues between looks at
+	// its batch and at the length of the email queue.
+	ingestCheckEvery = 100
+	// ingestQueuePoll is how often a waiting upload looks at the queue again.
+	ingestQueuePoll = time.Second
+)
+
+const (
+	IngestQueued   = "QUEUED"
+	IngestInvalid  = "INVALID"
+	IngestRejected = "REJECTED"
+	IngestFailed   = "FAILED"
+)
+
+type IngestEmailsRequest struct {
+	APIKey   string
+	ApiKeyID string
+	TenantID string
+	Quota    dto.RateLimitQuota
+}
+
+// IngestEmailResult is what became of one line of an upload.
+type IngestEmailResult struct {
+	Line      int                     `json:"line"`
+	Status    string                  `json:"status"`
+	ID        string                  `json:"id,omitempty"`
+	MessageID string                  `json:"message_id,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+	Errors    []error_wrap.FieldError `json:"errors,omitempty"`
+}
+
+type IngestEmailsSummary struct {
+	Batch    dto.Batch      `json:"batch"`
+	Queued   int64          `json:"queued"`
+	Failed   int64          `json:"failed"`
+	Rejected int64          `json:"rejected"`
+	Invalid  int64          `json:"invalid"`
+	Quota    dto.QuotaUsage `json:"quota"`
+	// Error tells why the upload stopped before its end.
+	Error string `json:"error,omitempty"`
+}
+
+// EmailIngestion queues the emails of one upload into a batch as they arrive.
+// It is not safe for concurrent use.
+type EmailIngestion struct {
+	usecase *emailUsecase
+	request IngestEmailsRequest
+	batch   dto.Batch
+	summary IngestEmailsSummary
+}
+
+// StartEmailIngestion creates the batch an upload is queued into. The batch
+// stays receiving until the ingestion is finished.
+func (u *emailUsecase) StartEmailIngestion(ctx context.Context, request IngestEmailsRequest) (*EmailIngestion, error) {
+	batch := dto.Batch{
+		TenantID: request.TenantID,
+		ApiKeyID: request.ApiKeyID,
+		Status:   dto.BatchReceiving,
+	}
+	if err := u.batchRepo.Create(ctx, &batch); err != nil {
+		logrus.Error("error creating email batch: ", err)
+		return nil, error_wrap.ErrSqlError
+	}
+
+	return &EmailIngestion{
+		usecase: u,
+		request: request,
+		batch:   batch,
+		summary: IngestEmailsSummary{Batch: batch},
+	}, nil
+}
+
+// Queue charges the quota of the email on line and queues it into the batch.
+// While the email queue is full it waits for the workers to catch up. The
+// returned error means the upload can not go on, as when the batch was
+// cancelled.
+func (i *EmailIngestion) Queue(ctx context.Context, line int, email dto.EmailTask) (IngestEmailResult, error) {
+	u := i.usecase
+	result := IngestEmailResult{Line: line}
+
+	handled := i.summary.Queued + i.summary.Failed + i.summary.Rejected
+	if handled%ingestCheckEvery == 0 {
+		if err := i.checkBatch(ctx); err != nil {
+			return result, err
+		}
+		if err := i.waitForQueue(ctx); err != nil {
+			return result, err
+		}
+	}
+
+	bucket, err := u.rateLimitter.Consume(ctx, i.request.APIKey, i.request.Quota, []int{max(len(email.Recipients()), 1)}, false)
+	if err != nil {
+		logrus.Error("error consuming quota: ", err)
+		return result, error_wrap.ErrIPorServiceBlocked
+	}
+	i.summary.Quota = bucket.Usage
+	if bucket.Accepted == 0 {
+		i.summary.Rejected++
+		result.Status = IngestRejected
+		result.Error = error_wrap.ErrTooManyRequests.Error()
+		return result, nil
+	}
+
+	history, err := u.queueBatchEmail(ctx, i.batch, email)
+	result.ID = history.ID
+	result.MessageID = history.MessageID
+	if err != nil {
+		i.summary.Failed++
+		result.Status = IngestFailed
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	i.summary.Queued++
+	result.Status = IngestQueued
+	return result, nil
+}
+
+// Invalid records that line does not hold a valid email.
+func (i *EmailIngestion) Invalid(line int, errs error_wrap.ValidationErrors) IngestEmailResult {
+	i.summary.Invalid++
+	return IngestEmailResult{
+		Line:   line,
+		Status: IngestInvalid,
+		Error:  errs.Error(),
+		Errors: errs,
+	}
+}
+
+// Finish hands the batch over to the workers like any other batch and sums up
+// the upload. stopErr tells why the upload stopped early, if it did.
+func (i *EmailIngestion) Finish(ctx context.Context, stopErr error) IngestEmailsSummary {
+	// The batch must leave receiving even when the client is gone
+	ctx = context.WithoutCancel(ctx)
+	u := i.usecase
+	log := logrus.WithField("batch_id", i.batch.ID)
+
+	// Paused and cancelled batches keep their status
+	if _, err := u.batchRepo.UpdateStatus(ctx, i.batch.ID, []string{dto.BatchReceiving}, dto.BatchActive); err != nil {
+		log.Error("error updating email batch status: ", err)
+	}
+
+	batch, err := u.batchRepo.FetchOne(ctx, repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{i.batch.ID},
+	})
+	if err != nil {
+		log.Error("error fetching email batch: ", err)
+		batch = i.batch
+	}
+
+	summary := i.summary
+	summary.Batch = reportBatch(batch)
+	if stopErr != nil {
+		summary.Error = stopErr.Error()
+	}
+	return summary
+}
+
+// checkBatch stops the upload once its batch is cancelled, pausing only holds
+// back sending.
+func (i *EmailIngestion) checkBatch(ctx context.Context) error {
+	batch, err := i.usecase.batchRepo.FetchOne(ctx, repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{i.batch.ID},
+	})
+	if err != nil {
+		logrus.WithField("batch_id", i.batch.ID).Error("error fetching email batch: ", err)
+		return error_wrap.ErrSqlError
+	}
+	if batch.Status == dto.BatchCancelled {
+		return error_wrap.ErrBadRequest
+	}
+	return nil
+}
+
+// waitForQueue blocks while the email queue holds the most emails uploads may
+// add to, so uploads go no faster than the workers send.
+func (i *EmailIngestion) waitForQueue(ctx context.Context) error {
+	u := i.usecase
+	maxLength := u.cfg.Bulk.MaxQueueLength
+	if maxLength <= 0 {
+		return nil
+	}
+
+	for {
+		length, err := u.redisClient.Len(ctx)
+		if err != nil {
+			logrus.Error("error reading email queue length: ", err)
+			return error_wrap.ErrInternalServerError
+		}
+		if length < maxLength {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ingestQueuePoll):
+		}
+	}
+}
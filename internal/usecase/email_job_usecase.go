@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"time"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/pkg/redis"
+	"worker-service/internal/repository"
+	"worker-service/internal/services"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	emailJobBatchSize = 100
+	maxBulkEmailIDs   = 10000
+	// maxQuotaWait bounds how long a retry job waits for quota before failing,
+	// so hourly and daily limits do not keep a job running for a day.
+	maxQuotaWait = 5 * time.Minute
+	// Running jobs are touched every emailJobHeartbeat. Jobs untouched for
+	// emailJobStaleAfter lost the process running them and are failed.
+	emailJobHeartbeat  = time.Minute
+	emailJobStaleAfter = 5 * time.Minute
+)
+
+// Bulk jobs only touch emails in a status they can act on.
+var (
+	retryableStatuses   = []dto.EmailHistoryStatus{dto.EmailHistoryPending, dto.EmailHistoryFailed}
+	cancellableStatuses = []dto.EmailHistoryStatus{dto.EmailHistoryPending, dto.EmailHistoryQueued}
+)
+
+type EmailJobUsecase interface {
+	RetryEmails(ctx context.Context, request BulkEmailJobRequest) (dto.BulkEmailResponse, error)
+	CancelEmails(ctx context.Context, request BulkEmailJobRequest) (dto.BulkEmailResponse, error)
+	GetEmailJob(ctx context.Context, scope dto.TenantScope, id string) (dto.EmailJob, error)
+	WatchJobs(ctx context.Context)
+}
+
+type emailJobUsecase struct {
+	emailHistoryRepo repository.EmailHistoryRepository
+	emailJobRepo     repository.EmailJobRepository
+	rateLimitter     *services.RateLimitter
+	statusChanges    emailStatusChanges
+	emailQueue       emailQueue
+}
+
+type BulkEmailJobRequest struct {
+	dto.TenantScope
+	dto.BulkEmailRequest
+	APIKey   string
+	ApiKeyID string
+	Quota    dto.RateLimitQuota
+}
+
+// processEmails handles one batch of a job and returns how many emails succeeded.
+type processEmails func(ctx context.Context, request BulkEmailJobRequest, emails []dto.EmailHistory) (int64, error)
+
+func NewEmailJobUsecase(emailHistoryRepo repository.EmailHistoryRepository, emailJobRepo repository.EmailJobRepository, emailService services.EmailService, redisClient *redis.RedisClient[dto.QueuedEmail], rateLimitter *services.RateLimitter, emailEvents *services.EmailEvents, batchRepo repository.BatchRepository) EmailJobUsecase {
+	statusChanges := emailStatusChanges{emailEvents: emailEvents, batchRepo: batchRepo}
+	return &emailJobUsecase{
+		emailHistoryRepo: emailHistoryRepo,
+		emailJobRepo:     emailJobRepo,
+		rateLimitter:     rateLimitter,
+		statusChanges:    statusChanges,
+		emailQueue: emailQueue{
+			redisClient:      redisClient,
+			emailHistoryRepo: emailHistoryRepo,
+			emailService:     emailService,
+			statusChanges:    statusChanges,
+		},
+	}
+}
+
+// RetryEmails queues pending and failed emails again for the workers, charging
+// the quota of the caller like a normal send.
+func (u *emailJobUsecase) RetryEmails(ctx context.Context, request BulkEmailJobRequest) (dto.BulkEmailResponse, error) {
+	return u.startJob(ctx, dto.EmailJobRetry, request, retryableStatuses, u.retryEmails)
+}
+
+// CancelEmails marks pending emails as cancelled so they are never retried.
+func (u *emailJobUsecase) CancelEmails(ctx context.Context, request BulkEmailJobRequest) (dto.BulkEmailResponse, error) {
+	return u.startJob(ctx, dto.EmailJobCancel, request, cancellableStatuses, u.cancelEmails)
+}
+
+func (u *emailJobUsecase) GetEmailJob(ctx context.Context, scope dto.TenantScope, id string) (dto.EmailJob, error) {
+	q := repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{id},
+	}
+	if !scope.AllTenants {
+		q.AddTermCondition(" tenant_id = ?", scope.TenantID)
+	}
+
+	job, err := u.emailJobRepo.FetchOne(ctx, q)
+	if err != nil {
+		logrus.Error("error fetching email job: ", err)
+		return dto.EmailJob{}, error_wrap.ErrNotFound
+	}
+
+	return job, nil
+}
+
+// WatchJobs fails the jobs left unfinished by a process that went away, now
+// and every emailJobHeartbeat until ctx is done.
+func (u *emailJobUsecase) WatchJobs(ctx context.Context) {
+	ticker := time.NewTicker(emailJobHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		failed, err := u.emailJobRepo.FailStale(ctx, time.Now().Add(-emailJobStaleAfter), "job stopped with the process running it")
+		if err != nil {
+			logrus.Error("error failing stale email jobs: ", err)
+		} else if failed > 0 {
+			logrus.Warnf("failed %d stale email jobs", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *emailJobUsecase) startJob(ctx context.Context, kind string, request BulkEmailJobRequest, statuses []dto.EmailHistoryStatus, process processEmails) (dto.BulkEmailResponse, error) {
+	q, err := buildBulkEmailQuery(request, statuses)
+	if err != nil {
+		return dto.BulkEmailResponse{}, err
+	}
+
+	total, err := u.emailHistoryRepo.Count(ctx, q)
+	if err != nil {
+		logrus.Error("error counting bulk emails: ", err)
+		return dto.BulkEmailResponse{}, error_wrap.ErrSqlError
+	}
+	if request.DryRun {
+		return dto.BulkEmailResponse{DryRun: true, Total: total}, nil
+	}
+
+	job := dto.EmailJob{
+		ID:       ulid.Make().String(),
+		TenantID: request.TenantID,
+		ApiKeyID: request.ApiKeyID,
+		Kind:     kind,
+		Status:   dto.EmailJobPending,
+		Filter:   request.BulkEmailRequest,
+		Total:    total,
+	}
+	if err := u.emailJobRepo.Create(ctx, &job); err != nil {
+		logrus.Error("error creating email job: ", err)
+		return dto.BulkEmailResponse{}, error_wrap.ErrSqlError
+	}
+
+	// The job outlives the request
+	go u.runJob(context.Background(), job, request, q, process)
+
+	return dto.BulkEmailResponse{Total: total, Job: &job}, nil
+}
+
+// runJob walks the matching emails in ID order, one batch at a time, and
+// records the progress after every batch.
+func (u *emailJobUsecase) runJob(ctx context.Context, job dto.EmailJob, request BulkEmailJobRequest, q repository.Query, process processEmails) {
+	log := logrus.WithFields(logrus.Fields{"job_id": job.ID, "kind": job.Kind})
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go u.heartbeat(heartbeatCtx, job.ID)
+
+	job.Status = dto.EmailJobRunning
+	u.saveJob(ctx, &job)
+
+	lastID := ""
+	for {
+		batch := q
+		batch.Values = slices.Clone(q.Values)
+		batch.Limit = emailJobBatchSize
+		if lastID != "" {
+			batch.AddTermCondition(" id > ?", lastID)
+		}
+
+		emails, err := u.emailHistoryRepo.Fetch(ctx, batch)
+		if err != nil {
+			log.Error("error fetching email job batch: ", err)
+			u.finishJob(ctx, &job, error_wrap.ErrSqlError)
+			return
+		}
+		if len(emails) == 0 {
+			break
+		}
+
+		succeeded, err := process(ctx, request, emails)
+		job.Processed += int64(len(emails))
+		job.Succeeded += succeeded
+		job.Failed += int64(len(emails)) - succeeded
+		if err != nil {
+			log.Error("error processing email job batch: ", err)
+			u.finishJob(ctx, &job, err)
+			return
+		}
+		u.saveJob(ctx, &job)
+
+		lastID = emails[len(emails)-1].ID
+	}
+
+	u.finishJob(ctx, &job, nil)
+	log.Infof("email job finished, %d succeeded, %d failed", job.Succeeded, job.Failed)
+}
+
+func (u *emailJobUsecase) retryEmails(ctx context.Context, request BulkEmailJobRequest, emails []dto.EmailHistory) (int64, error) {
+	var succeeded int64
+	for _, email := range emails {
+		task := dto.EmailTask{
+			From:    email.From,
+			To:      email.To,
+			Subject: email.Subject,
+			Body:    email.Body,
+		}
+
+		if err := u.waitForQuota(ctx, request, max(len(task.Recipients()), 1)); err != nil {
+			// The rest of the batch was not attempted
+			return succeeded, err
+		}
+
+		// Emails retried or cancelled since the batch was read count as failed
+		queued, err := u.emailQueue.requeue(ctx, email, task)
+		if err != nil {
+			logrus.WithField("email_id", email.ID).Error("error queueing email again: ", err)
+			continue
+		}
+		if queued {
+			succeeded++
+		}
+	}
+
+	return succeeded, nil
+}
+
+// waitForQuota blocks until cost fits in the quota of the caller.
+func (u *emailJobUsecase) waitForQuota(ctx context.Context, request BulkEmailJobRequest, cost int) error {
+	for {
+		bucket, err := u.rateLimitter.Take(ctx, request.APIKey, request.Quota, cost)
+		if err != nil {
+			return error_wrap.ErrIPorServiceBlocked
+		}
+		if bucket.Allowed() {
+			return nil
+		}
+
+		wait := time.Duration(bucket.Usage.RetryAfter) * time.Second
+		if wait <= 0 || wait > maxQuotaWait || cost > bucket.Usage.Limit {
+			return error_wrap.ErrTooManyRequests
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (u *emailJobUsecase) cancelEmails(ctx context.Context, request BulkEmailJobRequest, emails []dto.EmailHistory) (int64, error) {
+	ids := make([]string, 0, len(emails))
+	for _, email := range emails {
+		ids = append(ids, email.ID)
+	}
+
+	// Emails sent or cancelled since the batch was read are counted as failed
+	cancelled, err := u.emailHistoryRepo.UpdateStatus(ctx, ids, cancellableStatuses, dto.EmailHistoryCancelled)
+	if err != nil {
+		return 0, error_wrap.ErrSqlError
+	}
+
+	for _, email := range emails {
+		if slices.Contains(cancelled, email.ID) {
+			u.statusChanges.record(ctx, email, dto.EmailHistoryStatus(email.Status), dto.EmailHistoryCancelled, "")
+		}
+	}
+
+	return int64(len(cancelled)), nil
+}
+
+// heartbeat touches the job every emailJobHeartbeat until ctx is done.
+func (u *emailJobUsecase) heartbeat(ctx context.Context, id string) {
+	ticker := time.NewTicker(emailJobHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.emailJobRepo.Touch(ctx, id); err != nil {
+				logrus.WithField("job_id", id).Error("error touching email job: ", err)
+			}
+		}
+	}
+}
+
+func (u *emailJobUsecase) saveJob(ctx context.Context, job *dto.EmailJob) {
+	if err := u.emailJobRepo.Update(ctx, job.ID, job); err != nil {
+		logrus.Error("error updating email job: ", err)
+	}
+}
+
+func (u *emailJobUsecase) finishJob(ctx context.Context, job *dto.EmailJob, jobErr error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = dto.EmailJobCompleted
+	if jobErr != nil {
+		job.Status = dto.EmailJobFailed
+		job.Error = jobErr.Error()
+	}
+	u.saveJob(ctx, job)
+}
+
+// buildBulkEmailQuery selects the emails of a bulk request in one of statuses,
+// ordered by ID so jobs can walk them in batches.
+func buildBulkEmailQuery(request BulkEmailJobRequest, statuses []dto.EmailHistoryStatus) (repository.Query, error) {
+	if len(request.IDs) == 0 && len(request.Status) == 0 && request.CreatedFrom == nil && request.CreatedTo == nil && request.RecipientDomain == "" {
+		return repository.Query{}, error_wrap.ErrBadRequest
+	}
+	if len(request.IDs) > maxBulkEmailIDs {
+		return repository.Query{}, error_wrap.ErrBadRequest
+	}
+	for _, status := range request.Status {
+		if _, isExist := dto.EmailHistoryStatusTypeSelector[status]; !isExist {
+			return repository.Query{}, error_wrap.ErrBadRequest
+		}
+	}
+
+	query := ListEmailRequestQuery{
+		TenantScope:     request.TenantScope,
+		Status:          request.Status,
+		IDs:             request.IDs,
+		RecipientDomain: request.RecipientDomain,
+	}
+	if request.CreatedFrom != nil {
+		query.StartAt = *request.CreatedFrom
+	}
+	if request.CreatedTo != nil {
+		query.EndAt = *request.CreatedTo
+	}
+
+	q, err := buildEmailQueryDetail(query)
+	if err != nil {
+		return repository.Query{}, err
+	}
+	q.AddTermCondition(" status IN (?)", statuses)
+	q.Sort = "id"
+	q.TieBreaker = ""
+	q.Order = "ASC"
+
+	return q, nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/pkg/redis"
+	"worker-service/internal/repository"
+	"worker-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// emailQueue hands recorded emails over to the workers, whether they are new
+// or sent again.
+type emailQueue struct {
+	redisClient      *redis.RedisClient[dto.QueuedEmail]
+	emailHistoryRepo repository.EmailHistoryRepository
+	emailService     services.EmailService
+	statusChanges    emailStatusChanges
+}
+
+// enqueue puts the queued email history on the email queue. Emails that can
+// not be queued are left pending to be retried rather than queued forever.
+func (q emailQueue) enqueue(ctx context.Context, history dto.EmailHistory, mail dto.EmailTask) error {
+	queueErr := q.redisClient.Enqueue(ctx, dto.QueuedEmail{
+		EmailTask: mail,
+		EmailID:   history.ID,
+		MessageID: history.MessageID,
+	})
+	if queueErr == nil {
+		return nil
+	}
+
+	logrus.Error("error queueing email: ", queueErr)
+	if moved, err := q.emailHistoryRepo.UpdateStatus(ctx, []string{history.ID}, []dto.EmailHistoryStatus{dto.EmailHistoryQueued}, dto.EmailHistoryPending); err != nil {
+		logrus.Error("error updating email status: ", err)
+	} else if len(moved) > 0 {
+		q.statusChanges.record(ctx, history, dto.EmailHistoryQueued, dto.EmailHistoryPending, queueErr.Error())
+	}
+	return error_wrap.ErrInternalServerError
+}
+
+// requeue queues email again unless it left the status it was read in, as
+// when it was retried or cancelled meanwhile, and reports whether it did.
+func (q emailQueue) requeue(ctx context.Context, email dto.EmailHistory, mail dto.EmailTask) (bool, error) {
+	// Emails recorded before Message-IDs were picked when queueing get one now
+	if email.MessageID == "" {
+		email.MessageID = q.emailService.NewMessageID()
+		if err := q.emailHistoryRepo.Update(ctx, email.ID, &dto.EmailHistory{MessageID: email.MessageID}); err != nil {
+			logrus.Error("error updating email message id: ", err)
+			return false, error_wrap.ErrSqlError
+		}
+	}
+
+	from := dto.EmailHistoryStatus(email.Status)
+	moved, err := q.emailHistoryRepo.UpdateStatus(ctx, []string{email.ID}, []dto.EmailHistoryStatus{from}, dto.EmailHistoryQueued)
+	if err != nil {
+		logrus.Error("error updating email status: ", err)
+		return false, error_wrap.ErrSqlError
+	}
+	if len(moved) == 0 {
+		return false, nil
+	}
+	q.statusChanges.record(ctx, email, from, dto.EmailHistoryQueued, "")
+
+	return true, q.enqueue(ctx, email, mail)
+}
@@ -2,9 +2,10 @@ package usecase
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
-	"math"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 	"worker-service/config"
@@ -12,25 +13,49 @@ import (
 	"worker-service/internal/pkg/error_wrap"
 	"worker-service/internal/pkg/redis"
 	"worker-service/internal/repository"
-	"worker-service/internal/repository/unitofwork"
 	"worker-service/internal/services"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sourcegraph/conc/pool"
 )
 
+const (
+	subjectMatchContains = "contains"
+	subjectMatchPrefix   = "prefix"
+)
+
+// emailSortFields are the columns histories may be sorted by. Cursors hold
+// the sort value as a time, except for the search rank.
+var emailSortFields = []string{"created_at", "updated_at", emailSortRank}
+
+const (
+	emailSortRank = "rank"
+
+	emailSearchQuery    = "websearch_to_tsquery(?::regconfig, ?)"
+	emailRankExpression = "ts_rank(search_vector, " + emailSearchQuery + ")"
+	emailSnippetSelect  = "ts_headline(?::regconfig, coalesce(subject, '') || ' ' || regexp_replace(coalesce(body, ''), '<[^>]*>', ' ', 'g'), " +
+		emailSearchQuery + ", 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') AS snippet"
+)
+
 type EmailUsecase interface {
 	ListEmail(ctx context.Context, query ListEmailRequestQuery) (ListEmailResponse, error)
-	RetryEmail(ctx context.Context, id string) error
-	SendEmail(ctx context.Context, data []dto.EmailTask) (SendEmailResponse, error)
+	RetryEmail(ctx context.Context, request RetryEmailRequest) (dto.QuotaUsage, error)
+	SendEmail(ctx context.Context, request SendEmailRequest) (SendEmailResponse, error)
+	SendSingleEmail(ctx context.Context, request SendSingleEmailRequest) (SendSingleEmailResponse, error)
+	GetRawEmail(ctx context.Context, scope dto.TenantScope, id string) (dto.EmailMessage, error)
+	StartEmailIngestion(ctx context.Context, request IngestEmailsRequest) (*EmailIngestion, error)
 }
 
 type emailUsecase struct {
 	cfg              *config.AppConfig
 	emailHistoryRepo repository.EmailHistoryRepository
 	emailService     services.EmailService
-	uow              unitofwork.UnitOfWork
-	redisClient      *redis.RedisClient[dto.EmailTask]
+	redisClient      *redis.RedisClient[dto.QueuedEmail]
+	rateLimitter     *services.RateLimitter
+	messageStore     repository.EmailMessageStore
+	batchRepo        repository.BatchRepository
+	statusChanges    emailStatusChanges
+	emailQueue       emailQueue
 }
 
 type sendEmailWorkerResult struct {
@@ -38,130 +63,326 @@ type sendEmailWorkerResult struct {
 	Failed  map[string]string `json:"failed"`
 }
 
+type SendEmailRequest struct {
+	APIKey       string
+	ApiKeyID     string
+	TenantID     string
+	Quota        dto.RateLimitQuota
+	AllowPartial bool
+	Emails       []dto.EmailTask
+}
+
 type SendEmailResponse struct {
-	Success     int64               `json:"success"`
-	Failed      int64               `json:"failed"`
-	SuccessData []dto.EmailTask     `json:"success_data"`
-	FailedData  []map[string]string `json:"failed_data"`
+	Batch        dto.Batch           `json:"batch"`
+	Queued       int64               `json:"queued"`
+	Failed       int64               `json:"failed"`
+	Rejected     int64               `json:"rejected"`
+	FailedData   []map[string]string `json:"failed_data"`
+	RejectedData []dto.EmailTask     `json:"rejected_data"`
+	Quota        dto.QuotaUsage      `json:"quota"`
+}
+
+type RetryEmailRequest struct {
+	dto.TenantScope
+	APIKey string
+	Quota  dto.RateLimitQuota
+	ID     string
+}
+
+type SendSingleEmailRequest struct {
+	APIKey   string
+	ApiKeyID string
+	TenantID string
+	Quota    dto.RateLimitQuota
+	Email    dto.EmailTask
+	// Wait is how long to wait for the SMTP result, zero queues the email and
+	// returns at once.
+	Wait time.Duration
+}
+
+type QuotaExceededResponse struct {
+	RequestedItems      int            `json:"requested_items"`
+	RequestedRecipients int            `json:"requested_recipients"`
+	AvailableItems      int            `json:"available_items"`
+	Quota               dto.QuotaUsage `json:"quota"`
 }
 
 type ListEmailResponse struct {
-	Header PaginationHeader   `json:"header"`
+	Header CursorHeader       `json:"header"`
 	List   []dto.EmailHistory `json:"list"`
 }
 
 type ListEmailRequestQuery struct {
-	StartAt     time.Time
-	EndAt       time.Time
-	Page        int
-	Limit       int
-	IsAscending bool
-	ID          string
-	Status      []string
+	dto.TenantScope
+	StartAt      time.Time
+	EndAt        time.Time
+	UpdatedFrom  time.Time
+	UpdatedTo    time.Time
+	Cursor       string
+	Limit        int
+	IncludeTotal bool
+	Sort         string
+	IsAscending  bool
+	ID           string
+	Status       []string
+	To           string
+	From         string
+	Subject      string
+	SubjectMatch string
+	// Search is a web search style full text query over subject and body.
+	Search string
+	IDs    []string
+	// RecipientDomain matches emails with at least one recipient at the domain.
+	RecipientDomain string
+	BatchID         string
 }
 
-func NewEmailUsecase(cfg *config.AppConfig, emailHistoryRepo repository.EmailHistoryRepository, uow unitofwork.UnitOfWork, emailService services.EmailService, redisClient *redis.RedisClient[dto.EmailTask]) EmailUsecase {
+func NewEmailUsecase(cfg *config.AppConfig, emailHistoryRepo repository.EmailHistoryRepository, emailService services.EmailService, redisClient *redis.RedisClient[dto.QueuedEmail], rateLimitter *services.RateLimitter, messageStore repository.EmailMessageStore, emailEvents *services.EmailEvents, batchRepo repository.BatchRepository) EmailUsecase {
+	statusChanges := emailStatusChanges{emailEvents: emailEvents, batchRepo: batchRepo}
 	return &emailUsecase{
 		cfg:              cfg,
 		emailHistoryRepo: emailHistoryRepo,
-		uow:              uow,
 		emailService:     emailService,
 		redisClient:      redisClient,
+		rateLimitter:     rateLimitter,
+		messageStore:     messageStore,
+		batchRepo:        batchRepo,
+		statusChanges:    statusChanges,
+		emailQueue: emailQueue{
+			redisClient:      redisClient,
+			emailHistoryRepo: emailHistoryRepo,
+			emailService:     emailService,
+			statusChanges:    statusChanges,
+		},
 	}
 }
 
+// ListEmail pages through the history with a keyset on (sort column, id), so
+// pages stay stable while new emails are recorded. Counting every matching
+// row is opt-in as it is the expensive part on large tenants.
 func (u *emailUsecase) ListEmail(ctx context.Context, query ListEmailRequestQuery) (ListEmailResponse, error) {
-	q, err := u.buildEmailQueryDetail(query)
+	query.Limit = PageLimit(query.Limit)
+
+	q, err := buildEmailQueryDetail(query)
 	if err != nil {
-		return ListEmailResponse{}, error_wrap.ErrBadRequest
+		return ListEmailResponse{}, err
 	}
 
-	totalData, err := u.emailHistoryRepo.Count(ctx, q)
-	if err != nil {
-		return ListEmailResponse{}, error_wrap.ErrSqlError
+	header := CursorHeader{PerPage: int64(query.Limit)}
+	if query.IncludeTotal {
+		totalData, err := u.emailHistoryRepo.Count(ctx, q)
+		if err != nil {
+			logrus.Error("error counting email: ", err)
+			return ListEmailResponse{}, error_wrap.ErrSqlError
+		}
+		header.TotalData = &totalData
 	}
 
-	if totalData <= 0 {
-		return ListEmailResponse{
-			Header: PaginationHeader{
-				CurrentPage: int64(query.Page),
-				PerPage:     int64(query.Limit),
-				TotalData:   0,
-				TotalPages:  0,
-			},
-		}, nil
+	cursor := pageCursor{Sort: q.Sort, IsAscending: query.IsAscending}
+	if query.Cursor != "" {
+		cursor, err = decodeCursor(query.Cursor)
+		if err != nil || cursor.Sort != q.Sort || cursor.IsAscending != query.IsAscending {
+			return ListEmailResponse{}, error_wrap.ErrBadRequest
+		}
+
+		operator := "<"
+		if cursor.IsAscending != cursor.IsBackward {
+			operator = ">"
+		}
+		if q.Sort == emailSortRank {
+			q.AddTermConditions(fmt.Sprintf(" (%s, id) %s (?, ?)", emailRankExpression, operator),
+				repository.SearchLanguage, query.Search, cursor.Rank, cursor.ID)
+		} else {
+			q.AddTermConditions(fmt.Sprintf(" (%s, id) %s (?, ?)", q.Sort, operator), cursor.Value, cursor.ID)
+		}
 	}
 
-	totalPages := math.Ceil(float64(totalData) / float64(query.Limit))
-	q.Page = query.Page
-	q.Limit = query.Limit
-	if query.IsAscending {
+	// Walk backwards in reverse order and fetch one extra row to know whether
+	// another page follows
+	q.Order = "DESC"
+	if cursor.IsAscending != cursor.IsBackward {
 		q.Order = "ASC"
 	}
+	q.Limit = query.Limit + 1
 
 	data, err := u.emailHistoryRepo.Fetch(ctx, q)
 	if err != nil {
+		logrus.Error("error fetching email: ", err)
 		return ListEmailResponse{}, error_wrap.ErrSqlError
 	}
 
-	response := ListEmailResponse{
-		Header: PaginationHeader{
-			CurrentPage: int64(query.Page),
-			PerPage:     int64(query.Limit),
-			TotalData:   int64(len(data)),
-			TotalPages:  int64(totalPages),
-		},
-		List: data,
+	hasMore := len(data) > query.Limit
+	if hasMore {
+		data = data[:query.Limit]
+	}
+	if cursor.IsBackward {
+		slices.Reverse(data)
 	}
 
-	return response, nil
+	if len(data) > 0 {
+		hasPrev, hasNext := query.Cursor != "", hasMore
+		if cursor.IsBackward {
+			hasPrev, hasNext = hasMore, true
+		}
+		if hasPrev {
+			header.PrevCursor = emailCursor(data[0], q.Sort, query.IsAscending, true)
+		}
+		if hasNext {
+			header.NextCursor = emailCursor(data[len(data)-1], q.Sort, query.IsAscending, false)
+		}
+	}
+
+	return ListEmailResponse{
+		Header: header,
+		List:   data,
+	}, nil
 }
 
-func (u *emailUsecase) RetryEmail(ctx context.Context, id string) error {
+func emailCursor(email dto.EmailHistory, sort string, isAscending bool, isBackward bool) string {
+	cursor := pageCursor{
+		Sort:        sort,
+		Value:       email.CreatedAt,
+		Rank:        email.Rank,
+		ID:          email.ID,
+		IsAscending: isAscending,
+		IsBackward:  isBackward,
+	}
+	if sort == "updated_at" && email.UpdatedAt != nil {
+		cursor.Value = *email.UpdatedAt
+	}
+	return encodeCursor(cursor)
+}
+
+// RetryEmail queues a pending email again for the workers, charging the quota
+// per recipient like a new send.
+func (u *emailUsecase) RetryEmail(ctx context.Context, request RetryEmailRequest) (dto.QuotaUsage, error) {
+	id := request.ID
+
 	// Fetch the email
-	email, err := u.emailHistoryRepo.FetchOne(ctx, repository.Query{
+	q := repository.Query{
 		Query:  "id = ? AND status = ?",
 		Values: []interface{}{id, uint(dto.EmailHistoryPending)},
-	})
+	}
+	if !request.AllTenants {
+		q.AddTermCondition(" tenant_id = ?", request.TenantID)
+	}
+	email, err := u.emailHistoryRepo.FetchOne(ctx, q)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return dto.QuotaUsage{}, error_wrap.ErrNotFound
+	}
 	if err != nil {
 		logrus.Error("error fetching email: ", err)
-		return error_wrap.ErrSqlError
-	}
-
-	err = u.uow.Do(ctx, func(uows unitofwork.UnitOfWorkStore) error {
-		// Send the email
-		if err := u.emailService.SendEmail(ctx, dto.EmailTask{
-			From:    email.From,
-			To:      email.To,
-			Subject: email.Subject,
-			Body:    email.Body,
-		}); err != nil {
-			logrus.Error("error retry email: ", err)
-			return error_wrap.ErrInternalServerError
-		}
+		return dto.QuotaUsage{}, error_wrap.ErrSqlError
+	}
 
-		// Update email
-		email.Status = uint(dto.EmailHistorySuccess)
-		email.IsActive = false
-		if err := u.emailHistoryRepo.Update(ctx, id, &email); err != nil {
-			logrus.Error("error updating existing email: ", err)
-			return error_wrap.ErrSqlError
-		}
+	task := dto.EmailTask{
+		From:    email.From,
+		To:      email.To,
+		Subject: email.Subject,
+		Body:    email.Body,
+	}
+	cost := max(len(task.Recipients()), 1)
+	bucket, err := u.rateLimitter.Consume(ctx, request.APIKey, request.Quota, []int{cost}, false)
+	if err != nil {
+		logrus.Error("error consuming quota: ", err)
+		return dto.QuotaUsage{}, error_wrap.ErrIPorServiceBlocked
+	}
+	if bucket.Accepted == 0 {
+		return bucket.Usage, error_wrap.WithData(error_wrap.ErrTooManyRequests, QuotaExceededResponse{
+			RequestedItems:      1,
+			RequestedRecipients: cost,
+			AvailableItems:      bucket.Fit,
+			Quota:               bucket.Usage,
+		})
+	}
+
+	queued, err := u.emailQueue.requeue(ctx, email, task)
+	if err == nil && !queued {
+		// Retried or cancelled since it was read
+		return bucket.Usage, error_wrap.ErrNotFound
+	}
+	return bucket.Usage, err
+}
 
-		return nil
-	}, sql.TxOptions{Isolation: sql.LevelSerializable})
+// GetRawEmail returns the message as it was handed to the SMTP server on the
+// last successful send of the email.
+func (u *emailUsecase) GetRawEmail(ctx context.Context, scope dto.TenantScope, id string) (dto.EmailMessage, error) {
+	q := repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{id},
+	}
+	if !scope.AllTenants {
+		q.AddTermCondition(" tenant_id = ?", scope.TenantID)
+	}
+	if _, err := u.emailHistoryRepo.FetchOne(ctx, q); err != nil {
+		logrus.Error("error fetching email: ", err)
+		return dto.EmailMessage{}, error_wrap.ErrNotFound
+	}
+
+	message, err := u.messageStore.Get(ctx, id)
 	if err != nil {
-		logrus.Error("error uow: ", err)
-		return error_wrap.ErrInternalServerError
+		logrus.Error("error fetching email message: ", err)
+		return dto.EmailMessage{}, error_wrap.ErrNotFound
 	}
 
-	return nil
+	return message, nil
 }
 
-func (u *emailUsecase) SendEmail(ctx context.Context, data []dto.EmailTask) (SendEmailResponse, error) {
+// storeEmailMessage keeps the rendered message of a sent email. Failing to
+// store it does not fail the send.
+func storeEmailMessage(ctx context.Context, store repository.EmailMessageStore, emailID string, rendered dto.RenderedEmail) {
+	err := store.Put(ctx, dto.EmailMessage{
+		EmailID:   emailID,
+		MessageID: rendered.MessageID,
+		Raw:       rendered.Raw,
+	})
+	if err != nil {
+		logrus.WithField("email_id", emailID).Error("error storing email message: ", err)
+	}
+}
+
+// SendEmail charges the quota of every email up front, records the accepted
+// ones as a batch and puts them on the email queue.
+func (u *emailUsecase) SendEmail(ctx context.Context, request SendEmailRequest) (SendEmailResponse, error) {
+	// Charge the quota per recipient before queueing anything
+	var recipients int
+	costs := make([]int, 0, len(request.Emails))
+	for _, email := range request.Emails {
+		cost := max(len(email.Recipients()), 1)
+		costs = append(costs, cost)
+		recipients += cost
+	}
+
+	bucket, err := u.rateLimitter.Consume(ctx, request.APIKey, request.Quota, costs, request.AllowPartial)
+	if err != nil {
+		logrus.Error("error consuming quota: ", err)
+		return SendEmailResponse{}, error_wrap.ErrIPorServiceBlocked
+	}
+
+	if bucket.Accepted == 0 && len(request.Emails) > 0 {
+		return SendEmailResponse{Quota: bucket.Usage}, error_wrap.WithData(error_wrap.ErrTooManyRequests, QuotaExceededResponse{
+			RequestedItems:      len(request.Emails),
+			RequestedRecipients: recipients,
+			AvailableItems:      bucket.Fit,
+			Quota:               bucket.Usage,
+		})
+	}
+
+	data := request.Emails[:bucket.Accepted]
+	rejected := request.Emails[bucket.Accepted:]
+
+	batch := dto.Batch{
+		TenantID: request.TenantID,
+		ApiKeyID: request.ApiKeyID,
+		Status:   dto.BatchActive,
+	}
+	if err := u.batchRepo.Create(ctx, &batch); err != nil {
+		logrus.Error("error creating email batch: ", err)
+		return SendEmailResponse{Quota: bucket.Usage}, error_wrap.ErrSqlError
+	}
+
 	var (
-		resChan = make(chan sendEmailWorkerResult, len(data))
-		success []dto.EmailTask
+		resChan = make(chan map[string]string, len(data))
 		failed  []map[string]string
 	)
 
@@ -170,66 +391,182 @@ func (u *emailUsecase) SendEmail(ctx context.Context, data []dto.EmailTask) (Sen
 		mail := d
 
 		pooler.Go(func() {
-			mappingError := make(map[string]string)
-			key := fmt.Sprintf("%s:%s", mail.To, d.Subject)
-			if sendErr := u.emailService.SendEmail(ctx, mail); sendErr != nil {
-				logrus.Error("error sending email: ", sendErr)
-				createErr := u.emailHistoryRepo.Create(ctx, &dto.EmailHistory{
-					From:     mail.From,
-					To:       mail.To,
-					Subject:  mail.Subject,
-					Body:     mail.Body,
-					Status:   uint(dto.EmailHistoryPending),
-					IsActive: true,
-				})
-				if createErr != nil {
-					logrus.Error("error creating email history: ", createErr)
-					mappingError[key] = createErr.Error()
-				} else {
-					mappingError[key] = sendErr.Error()
-				}
-				resChan <- sendEmailWorkerResult{Failed: mappingError}
-				return
+			if _, err := u.queueBatchEmail(ctx, batch, mail); err != nil {
+				resChan <- map[string]string{fmt.Sprintf("%s:%s", mail.To, mail.Subject): err.Error()}
 			}
-
-			resChan <- sendEmailWorkerResult{Success: mail}
 		})
 	}
 	pooler.Wait()
 	close(resChan)
 
 	for res := range resChan {
-		if res.Failed != nil {
-			failed = append(failed, res.Failed)
-		}
-		if res.Success != (dto.EmailTask{}) {
-			success = append(success, res.Success)
-		}
+		failed = append(failed, res)
 	}
 
-	successCount := int64(len(success))
-	failedCount := int64(len(failed))
+	// Report the counts as they are, workers may have started on the batch
+	if current, err := u.batchRepo.FetchOne(ctx, repository.Query{
+		Query:  "id = ?",
+		Values: []interface{}{batch.ID},
+	}); err != nil {
+		logrus.Error("error fetching email batch: ", err)
+	} else {
+		batch = current
+	}
 
 	var response = SendEmailResponse{
-		Success:     successCount,
-		Failed:      failedCount,
-		SuccessData: success,
-		FailedData:  failed,
+		Batch:        reportBatch(batch),
+		Queued:       int64(len(data) - len(failed)),
+		Failed:       int64(len(failed)),
+		Rejected:     int64(len(rejected)),
+		FailedData:   failed,
+		RejectedData: rejected,
+		Quota:        bucket.Usage,
+	}
+
+	return response, nil
+}
+
+// queueBatchEmail records mail as a queued email of batch and puts it on the
+// email queue. The history is returned even when queueing fails after it was
+// recorded.
+func (u *emailUsecase) queueBatchEmail(ctx context.Context, batch dto.Batch, mail dto.EmailTask) (dto.EmailHistory, error) {
+	history := dto.EmailHistory{
+		TenantID:  batch.TenantID,
+		ApiKeyID:  batch.ApiKeyID,
+		MessageID: u.emailService.NewMessageID(),
+		BatchID:   batch.ID,
+		From:      mail.From,
+		To:        mail.To,
+		Subject:   mail.Subject,
+		Body:      mail.Body,
+		Status:    uint(dto.EmailHistoryQueued),
+		IsActive:  true,
+	}
+	if err := u.emailHistoryRepo.Create(ctx, &history); err != nil {
+		logrus.Error("error creating email history: ", err)
+		return dto.EmailHistory{}, error_wrap.ErrSqlError
+	}
+	if err := u.batchRepo.AddCounts(ctx, batch.ID, 1, map[dto.EmailHistoryStatus]int64{dto.EmailHistoryQueued: 1}); err != nil {
+		logrus.WithField("batch_id", batch.ID).Error("error counting batch emails: ", err)
+	}
+	u.statusChanges.publish(ctx, history, dto.EmailHistoryQueued, "")
+
+	return history, u.emailQueue.enqueue(ctx, history, mail)
+}
+
+// SendSingleEmail records email as queued and puts it on the email queue. When
+// the caller waits, the result of the send by the workers is awaited up to
+// that long, past it the email is reported as still queued.
+func (u *emailUsecase) SendSingleEmail(ctx context.Context, request SendSingleEmailRequest) (SendSingleEmailResponse, error) {
+	cost := len(request.Email.Recipients())
+	if cost == 0 {
+		return SendSingleEmailResponse{}, error_wrap.ErrBadRequest
+	}
+
+	bucket, err := u.rateLimitter.Consume(ctx, request.APIKey, request.Quota, []int{cost}, false)
+	if err != nil {
+		logrus.Error("error consuming quota: ", err)
+		return SendSingleEmailResponse{}, error_wrap.ErrIPorServiceBlocked
+	}
+	if bucket.Accepted == 0 {
+		return SendSingleEmailResponse{Quota: bucket.Usage}, error_wrap.WithData(error_wrap.ErrTooManyRequests, QuotaExceededResponse{
+			RequestedItems:      1,
+			RequestedRecipients: cost,
+			AvailableItems:      bucket.Fit,
+			Quota:               bucket.Usage,
+		})
+	}
+
+	history := dto.EmailHistory{
+		TenantID:  request.TenantID,
+		ApiKeyID:  request.ApiKeyID,
+		MessageID: u.emailService.NewMessageID(),
+		From:      request.Email.From,
+		To:        request.Email.To,
+		Subject:   request.Email.Subject,
+		Body:      request.Email.Body,
+		Status:    uint(dto.EmailHistoryQueued),
+		IsActive:  true,
+	}
+	if err := u.emailHistoryRepo.Create(ctx, &history); err != nil {
+		logrus.Error("error creating email history: ", err)
+		return SendSingleEmailResponse{Quota: bucket.Usage}, error_wrap.ErrSqlError
+	}
+	u.statusChanges.publish(ctx, history, dto.EmailHistoryQueued, "")
+
+	response := SendSingleEmailResponse{
+		ID:        history.ID,
+		MessageID: history.MessageID,
+		Status:    dto.EmailHistoryStatusToString[dto.EmailHistoryQueued],
+		Quota:     bucket.Usage,
+	}
+
+	if request.Wait <= 0 {
+		return response, u.emailQueue.enqueue(ctx, history, request.Email)
+	}
+
+	// Subscribe before queueing so the result can not be missed
+	waitCtx, cancel := context.WithTimeout(ctx, request.Wait)
+	defer cancel()
+	events, err := u.statusChanges.emailEvents.Subscribe(waitCtx, "", func(event dto.EmailStatusEvent) bool {
+		return event.EmailID == history.ID && event.Status != response.Status
+	})
+	if err != nil {
+		// The email is still sent, only its result is not awaited
+		logrus.Error("error subscribing to email events: ", err)
+		return response, u.emailQueue.enqueue(ctx, history, request.Email)
 	}
 
+	if err := u.emailQueue.enqueue(ctx, history, request.Email); err != nil {
+		return response, err
+	}
+
+	// The channel is closed once the wait is over
+	if event, ok := <-events; ok {
+		response.Status = event.Status
+		response.Error = event.Error
+		response.SmtpResponse = event.SmtpResponse
+	}
 	return response, nil
 }
 
-func (u *emailUsecase) buildEmailQueryDetail(request ListEmailRequestQuery) (repository.Query, error) {
+func buildEmailQueryDetail(request ListEmailRequestQuery) (repository.Query, error) {
 	query := []string{}
 	emailHistoryQuery := repository.Query{
-		Sort:  "updated_at",
-		Order: "DESC",
+		Sort:       "updated_at",
+		TieBreaker: "id",
+		Order:      "DESC",
+	}
+
+	if request.Sort != "" {
+		if !slices.Contains(emailSortFields, request.Sort) || (request.Sort == emailSortRank && request.Search == "") {
+			return repository.Query{}, error_wrap.ErrBadRequest
+		}
+		emailHistoryQuery.Sort = request.Sort
+	}
+
+	// Searches select their rank and a highlighted snippet, and are sorted by
+	// relevance unless asked otherwise
+	if request.Search != "" {
+		query = append(query, "search_vector @@ "+emailSearchQuery)
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, repository.SearchLanguage, request.Search)
+		emailHistoryQuery.SelectQuery = "email_histories.*, " + emailRankExpression + " AS rank, " + emailSnippetSelect
+		emailHistoryQuery.SelectArgs = []interface{}{
+			repository.SearchLanguage, request.Search,
+			repository.SearchLanguage, repository.SearchLanguage, request.Search,
+		}
+		if request.Sort == "" {
+			emailHistoryQuery.Sort = emailSortRank
+		}
 	}
 
 	var listStatus []dto.EmailHistoryStatus
 	for _, item := range request.Status {
-		listStatus = append(listStatus, dto.EmailHistoryStatusTypeSelector[item])
+		status, isExist := dto.EmailHistoryStatusTypeSelector[item]
+		if !isExist {
+			return repository.Query{}, error_wrap.ErrBadRequest
+		}
+		listStatus = append(listStatus, status)
 	}
 
 	if len(listStatus) > 0 {
@@ -237,17 +574,73 @@ func (u *emailUsecase) buildEmailQueryDetail(request ListEmailRequestQuery) (rep
 		emailHistoryQuery.Values = append(emailHistoryQuery.Values, listStatus)
 	}
 
+	if !request.AllTenants {
+		if request.TenantID == "" {
+			return repository.Query{}, error_wrap.ErrForbidden
+		}
+		query = append(query, "tenant_id = ?")
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, request.TenantID)
+	}
+
 	if request.ID != "" {
 		query = append(query, "id = ?")
 		emailHistoryQuery.Values = append(emailHistoryQuery.Values, request.ID)
 	}
 
-	if !request.StartAt.IsZero() && !request.EndAt.IsZero() {
-		if request.StartAt.After(request.EndAt) {
+	if len(request.IDs) > 0 {
+		query = append(query, "id IN (?)")
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, request.IDs)
+	}
+
+	if request.BatchID != "" {
+		query = append(query, "batch_id = ?")
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, request.BatchID)
+	}
+
+	if request.RecipientDomain != "" {
+		query = append(query, `"to" ~* ?`)
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, "@"+regexp.QuoteMeta(strings.TrimPrefix(request.RecipientDomain, "@"))+`\s*(,|>|$)`)
+	}
+
+	if request.To != "" {
+		query = append(query, `"to" ILIKE ?`)
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, "%"+escapeLike(request.To)+"%")
+	}
+
+	if request.From != "" {
+		query = append(query, `"from" ILIKE ?`)
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, "%"+escapeLike(request.From)+"%")
+	}
+
+	if request.Subject != "" {
+		switch request.SubjectMatch {
+		case "", subjectMatchContains:
+			query = append(query, "subject ILIKE ?")
+			emailHistoryQuery.Values = append(emailHistoryQuery.Values, "%"+escapeLike(request.Subject)+"%")
+		case subjectMatchPrefix:
+			query = append(query, "subject ILIKE ?")
+			emailHistoryQuery.Values = append(emailHistoryQuery.Values, escapeLike(request.Subject)+"%")
+		default:
 			return repository.Query{}, error_wrap.ErrBadRequest
 		}
-		emailHistoryQuery.StartAt = request.StartAt
-		emailHistoryQuery.EndAt = request.EndAt
+	}
+
+	if !request.StartAt.IsZero() && !request.EndAt.IsZero() && request.StartAt.After(request.EndAt) {
+		return repository.Query{}, error_wrap.ErrBadRequest
+	}
+	emailHistoryQuery.StartAt = request.StartAt
+	emailHistoryQuery.EndAt = request.EndAt
+
+	if !request.UpdatedFrom.IsZero() && !request.UpdatedTo.IsZero() && request.UpdatedFrom.After(request.UpdatedTo) {
+		return repository.Query{}, error_wrap.ErrBadRequest
+	}
+	if !request.UpdatedFrom.IsZero() {
+		query = append(query, "updated_at >= ?")
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, request.UpdatedFrom)
+	}
+	if !request.UpdatedTo.IsZero() {
+		query = append(query, "updated_at <= ?")
+		emailHistoryQuery.Values = append(emailHistoryQuery.Values, request.UpdatedTo)
 	}
 
 	emailHistoryQuery.Query = strings.Join(query, " AND ")
@@ -1,8 +1,82 @@
 package usecase
 
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+	"worker-service/internal/pkg/error_wrap"
+)
+
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
 type PaginationHeader struct {
 	CurrentPage int64 `json:"current_page"`
 	PerPage     int64 `json:"per_page"`
 	TotalData   int64 `json:"total_data"`
 	TotalPages  int64 `json:"total_pages"`
 }
+
+// PageLimit defaults limits below 1 and caps the rest at MaxPageLimit.
+func PageLimit(limit int) int {
+	if limit < 1 {
+		return DefaultPageLimit
+	}
+	return min(limit, MaxPageLimit)
+}
+
+// HashAPIKey returns the stored form of a base64 decoded api key secret.
+func HashAPIKey(secret []byte) string {
+	hash := sha256.Sum256(secret)
+	return hex.EncodeToString(hash[:])
+}
+
+// CursorHeader describes a keyset page. Cursors are opaque to clients, an
+// empty cursor means there is no page in that direction. TotalData is only
+// counted on request.
+type CursorHeader struct {
+	PerPage    int64  `json:"per_page"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	TotalData  *int64 `json:"total_data,omitempty"`
+}
+
+// pageCursor points at the row a page starts after, by its sort column value
+// and ID. Backward cursors walk towards the start of the listing.
+type pageCursor struct {
+	Sort        string    `json:"s"`
+	Value       time.Time `json:"v"`
+	Rank        float64   `json:"r,omitempty"`
+	ID          string    `json:"i"`
+	IsAscending bool      `json:"a,omitempty"`
+	IsBackward  bool      `json:"b,omitempty"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, error_wrap.ErrBadRequest
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return pageCursor{}, error_wrap.ErrBadRequest
+	}
+	return cursor, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes user input match literally inside a LIKE pattern.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/pkg/redis"
+
+	"github.com/sirupsen/logrus"
+)
+
+type QueueUsecase interface {
+	InspectEmailQueue(ctx context.Context, limit int) (QueueInspectionResponse, error)
+}
+
+type queueUsecase struct {
+	emailQueue *redis.RedisClient[dto.QueuedEmail]
+}
+
+type QueueInspectionResponse struct {
+	Length int64             `json:"length"`
+	Next   []dto.QueuedEmail `json:"next"`
+}
+
+func NewQueueUsecase(emailQueue *redis.RedisClient[dto.QueuedEmail]) QueueUsecase {
+	return &queueUsecase{
+		emailQueue: emailQueue,
+	}
+}
+
+func (u *queueUsecase) InspectEmailQueue(ctx context.Context, limit int) (QueueInspectionResponse, error) {
+	length, err := u.emailQueue.Len(ctx)
+	if err != nil {
+		logrus.Error("error fetching email queue length: ", err)
+		return QueueInspectionResponse{}, error_wrap.ErrInternalServerError
+	}
+
+	next, err := u.emailQueue.Peek(ctx, int64(limit))
+	if err != nil {
+		logrus.Error("error peeking email queue: ", err)
+		return QueueInspectionResponse{}, error_wrap.ErrInternalServerError
+	}
+
+	return QueueInspectionResponse{
+		Length: length,
+		Next:   next,
+	}, nil
+}
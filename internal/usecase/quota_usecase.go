@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"worker-service/internal/dto"
+	"worker-service/internal/pkg/error_wrap"
+	"worker-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+type QuotaUsecase interface {
+	GetUsage(ctx context.Context, apiKey string, quota dto.RateLimitQuota) (dto.QuotaUsage, error)
+}
+
+type quotaUsecase struct {
+	rateLimitter *services.RateLimitter
+}
+
+func NewQuotaUsecase(rateLimitter *services.RateLimitter) QuotaUsecase {
+	return &quotaUsecase{
+		rateLimitter: rateLimitter,
+	}
+}
+
+func (u *quotaUsecase) GetUsage(ctx context.Context, apiKey string, quota dto.RateLimitQuota) (dto.QuotaUsage, error) {
+	usage, err := u.rateLimitter.Usage(ctx, apiKey, quota)
+	if err != nil {
+		logrus.Error("error fetching quota usage: ", err)
+		return dto.QuotaUsage{}, error_wrap.ErrInternalServerError
+	}
+
+	return usage, nil
+}